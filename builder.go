@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+)
+
+type RouteBuilder struct {
+	name       string
+	pattern    string
+	methods    []string
+	handler    http.Handler
+	meta       map[string]interface{}
+	middleware []func(http.Handler) http.Handler
+}
+
+func NewRoute(name string) RouteBuilder {
+	return RouteBuilder{name: name}
+}
+
+func (builder RouteBuilder) Pattern(pattern string) RouteBuilder {
+	builder.pattern = pattern
+	return builder
+}
+
+func (builder RouteBuilder) Methods(methods ...string) RouteBuilder {
+	builder.methods = append(builder.methods[:len(builder.methods):len(builder.methods)], methods...)
+	return builder
+}
+
+func (builder RouteBuilder) Handler(handler http.Handler) RouteBuilder {
+	builder.handler = handler
+	return builder
+}
+
+func (builder RouteBuilder) HandlerFunc(fn func(http.ResponseWriter, *http.Request)) RouteBuilder {
+	builder.handler = http.HandlerFunc(fn)
+	return builder
+}
+
+func (builder RouteBuilder) Meta(key string, value interface{}) RouteBuilder {
+	var meta = make(map[string]interface{}, len(builder.meta)+1)
+
+	for k, v := range builder.meta {
+		meta[k] = v
+	}
+
+	meta[key] = value
+	builder.meta = meta
+
+	return builder
+}
+
+func (builder RouteBuilder) Use(middleware ...func(http.Handler) http.Handler) RouteBuilder {
+	builder.middleware = append(builder.middleware[:len(builder.middleware):len(builder.middleware)], middleware...)
+	return builder
+}
+
+func (builder RouteBuilder) Build() (*Route, error) {
+	if builder.handler == nil {
+		return nil, errors.New("Missing handler!")
+	}
+
+	var route, err = compile(builder.pattern, builder.handler, builder.name, builder.methods)
+
+	if err != nil {
+		return nil, err
+	}
+
+	route.meta = builder.meta
+	route.middleware = append([]func(http.Handler) http.Handler(nil), builder.middleware...)
+
+	return route, nil
+}
@@ -0,0 +1,86 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRouteBuilder(test *testing.T) {
+	var users = NewRoute("users").Methods(http.MethodGet).Handler(&Handler{}).Meta("scope", "read")
+	var detail, err = users.Pattern("/users/:id").Meta("scope", "write").Build()
+
+	if err != nil {
+		test.Fatal(err)
+	} else if scope, _ := detail.Meta("scope"); scope != "write" {
+		test.Fatalf("Expected write, got %v", scope)
+	} else if !reflect.DeepEqual(detail.Methods, []string{http.MethodGet}) {
+		test.Fatalf("Unexpected methods: %v", detail.Methods)
+	}
+
+	if list, err := users.Pattern("/users").Build(); err != nil {
+		test.Fatal(err)
+	} else if scope, _ := list.Meta("scope"); scope != "read" {
+		test.Fatalf("Expected template to be unchanged, got %v", scope)
+	}
+
+	for _, builder := range []RouteBuilder{
+		users.Pattern("/users/:id:number"),
+		users.Pattern("/users/:id?/posts"),
+		NewRoute("missing").Pattern("/missing"),
+	} {
+		if _, err := builder.Build(); err == nil {
+			test.Fatalf("Expected error for %s", builder.pattern)
+		}
+	}
+
+	var router = New()
+	router.AddRoute(detail)
+
+	if handler, parameters := router.Resolve("/users/1"); handler == nil || !reflect.DeepEqual(parameters, []string{"1"}) {
+		test.Fatalf("Unexpected match: %v", parameters)
+	} else if path, err := router.Reverse("users", "1"); err != nil || path != "/users/1" {
+		test.Fatalf("Unexpected path: %s", path)
+	}
+}
+
+func TestRouteBuilderUnnamedParameter(test *testing.T) {
+	var tests = map[string]string{
+		"/users/:":          "Unnamed parameter ':' at segment 2 in '/users/:'!",
+		"/users/::int/edit": "Unnamed parameter '::int' at segment 2 in '/users/::int/edit'!",
+		"/files/*?":         "Unnamed parameter '*' at segment 2 in '/files/*?'!",
+	}
+
+	for pattern, expected := range tests {
+		if _, err := NewRoute("route").Pattern(pattern).Handler(&Handler{}).Build(); err == nil || err.Error() != expected {
+			test.Fatalf("Expected %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestRouteBuilderHandlerFunc(test *testing.T) {
+	var served []string
+	var handler = func(name string) func(http.ResponseWriter, *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			served = append(served, name+":"+Param(r.Context(), "id"))
+		}
+	}
+
+	var route, err = NewRoute("user").Pattern("/users/:id").HandlerFunc(handler("builder")).Build()
+
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	var router = New()
+	router.AddRoute(route)
+	router.AddFunc("/posts/:id", handler("router"), "post", http.MethodGet)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts/2", nil))
+
+	if expected := []string{"builder:1", "router:2"}; !reflect.DeepEqual(served, expected) {
+		test.Fatalf("Expected %v, got %v", expected, served)
+	}
+}
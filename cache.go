@@ -0,0 +1,126 @@
+package routes
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type lock struct {
+	sync.RWMutex
+
+	version uint64
+}
+
+func (lock *lock) Unlock() {
+	lock.version++
+	lock.RWMutex.Unlock()
+}
+
+type entry struct {
+	path       string
+	fold       bool
+	version    uint64
+	found      bool
+	leaf       *Router
+	parameters []string
+	names      []string
+	prefixes   []string
+	namespaces []string
+	middleware []func(http.Handler) http.Handler
+	notFound   http.Handler
+	notAllowed http.Handler
+	owner      *Router
+	depth      int
+	timeout    time.Duration
+	onError    func(http.ResponseWriter, *http.Request, error)
+}
+
+type cacheKey struct {
+	path string
+	fold bool
+}
+
+type cache struct {
+	mutex   sync.Mutex
+	size    int
+	order   *list.List
+	entries map[cacheKey]*list.Element
+}
+
+func newCache(size int) *cache {
+	return &cache{size: size, order: list.New(), entries: make(map[cacheKey]*list.Element, size)}
+}
+
+func (cache *cache) get(path string, fold bool, version uint64, m *match) (bool, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	var element, ok = cache.entries[cacheKey{path: path, fold: fold}]
+
+	if !ok {
+		return false, false
+	} else if e := element.Value.(*entry); e.version != version {
+		cache.order.Remove(element)
+		delete(cache.entries, cacheKey{path: path, fold: fold})
+		return false, false
+	} else {
+		cache.order.MoveToFront(element)
+
+		m.leaf = e.leaf
+		m.parameters = append(make([]string, 0, len(e.parameters)), e.parameters...)
+		m.names = e.names[:len(e.names):len(e.names)]
+		m.prefixes = e.prefixes[:len(e.prefixes):len(e.prefixes)]
+		m.namespaces = e.namespaces[:len(e.namespaces):len(e.namespaces)]
+		m.middleware = e.middleware[:len(e.middleware):len(e.middleware)]
+		m.notFound = e.notFound
+		m.notAllowed = e.notAllowed
+		m.owner, m.depth = e.owner, e.depth
+		m.timeout = e.timeout
+		m.onError = e.onError
+
+		return e.found, true
+	}
+}
+
+func (cache *cache) put(path string, fold bool, version uint64, found bool, m *match) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	var key = cacheKey{path: path, fold: fold}
+	var e = &entry{
+		path:       path,
+		fold:       fold,
+		version:    version,
+		found:      found,
+		leaf:       m.leaf,
+		parameters: append([]string(nil), m.parameters...),
+		names:      m.names[:len(m.names):len(m.names)],
+		prefixes:   m.prefixes[:len(m.prefixes):len(m.prefixes)],
+		namespaces: m.namespaces[:len(m.namespaces):len(m.namespaces)],
+		middleware: m.middleware[:len(m.middleware):len(m.middleware)],
+		notFound:   m.notFound,
+		notAllowed: m.notAllowed,
+		owner:      m.owner,
+		depth:      m.depth,
+		timeout:    m.timeout,
+		onError:    m.onError,
+	}
+
+	if element, ok := cache.entries[key]; ok {
+		element.Value = e
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	cache.entries[key] = cache.order.PushFront(e)
+
+	if cache.order.Len() > cache.size {
+		var oldest = cache.order.Back()
+		var stale = oldest.Value.(*entry)
+
+		cache.order.Remove(oldest)
+		delete(cache.entries, cacheKey{path: stale.path, fold: stale.fold})
+	}
+}
@@ -0,0 +1,86 @@
+package routes
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCache(test *testing.T) {
+	var user, me = &Handler{}, &Handler{}
+
+	var api = New()
+	api.Add("/users/:id", user, "user")
+
+	var router = New(WithCache(2))
+	router.AddRouter("/api", api, "api")
+
+	if handler, parameters := router.Resolve("/api/users/me"); handler != user || !reflect.DeepEqual(parameters, []string{"me"}) {
+		test.Fatalf("Unexpected match: %v", parameters)
+	} else {
+		parameters[0] = "changed"
+	}
+
+	if _, parameters := router.Resolve("/api/users/me"); !reflect.DeepEqual(parameters, []string{"me"}) {
+		test.Fatalf("Expected cached parameters to be unaffected, got %v", parameters)
+	}
+
+	api.Add("/users/me", me, "me")
+
+	if handler, _ := router.Resolve("/api/users/me"); handler != me {
+		test.Fatal("Expected cache to be invalidated by Add!")
+	}
+
+	api.Remove("me")
+
+	if handler, _ := router.Resolve("/api/users/me"); handler == me {
+		test.Fatal("Expected cache to be invalidated by Remove!")
+	}
+
+	for i := 0; i < 5; i++ {
+		router.Resolve(fmt.Sprintf("/api/users/%d", i))
+	}
+
+	if size := router.cache.order.Len(); size != 2 || len(router.cache.entries) != 2 {
+		test.Fatalf("Expected 2 entries, got %d", size)
+	}
+}
+
+func TestCacheConcurrent(test *testing.T) {
+	var router = New(WithCache(16))
+	router.Add("/users/:id/posts/:post", &Handler{}, "post")
+
+	var group sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		group.Add(1)
+
+		go func(i int) {
+			defer group.Done()
+
+			for j := 0; j < 100; j++ {
+				var path = fmt.Sprintf("/users/%d/posts/%d", i, j%4)
+
+				if _, parameters := router.Resolve(path); !reflect.DeepEqual(parameters, []string{fmt.Sprint(i), fmt.Sprint(j % 4)}) {
+					test.Errorf("Unexpected parameters for %s: %v", path, parameters)
+					return
+				}
+			}
+		}(i)
+	}
+
+	group.Wait()
+}
+
+func BenchmarkRouterLargeCached(benchmark *testing.B) {
+	var router = New(WithCache(128))
+
+	for i := 0; i < 2000; i++ {
+		router.Add(fmt.Sprintf("/resource%d/:id/items/%d", i%200, i), &Handler{}, "")
+	}
+
+	for i := 0; i < benchmark.N; i++ {
+		router.Resolve("/resource199/42/items/1999")
+	}
+}
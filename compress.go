@@ -0,0 +1,185 @@
+package routes
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const minimumCompressed = 512
+
+type compressWriter struct {
+	http.ResponseWriter
+
+	level   int
+	status  int
+	buffer  []byte
+	gzip    *gzip.Writer
+	decided bool
+}
+
+func accepts(header, encoding string) bool {
+	var wildcard = false
+
+	for _, part := range strings.Split(header, ",") {
+		var fields = strings.Split(part, ";")
+		var name, quality = strings.ToLower(strings.TrimSpace(fields[0])), 1.0
+
+		for _, field := range fields[1:] {
+			if field = strings.TrimSpace(field); strings.HasPrefix(field, "q=") {
+				if q, err := strconv.ParseFloat(field[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if name == encoding {
+			return quality > 0
+		} else if name == "*" {
+			wildcard = quality > 0
+		}
+	}
+
+	return wildcard
+}
+
+func Compress(level int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			response.Header().Add("Vary", "Accept-Encoding")
+
+			if request.Method == http.MethodHead || !accepts(request.Header.Get("Accept-Encoding"), "gzip") {
+				next.ServeHTTP(response, request)
+				return
+			}
+
+			var writer = &compressWriter{ResponseWriter: response, level: level}
+			defer writer.close()
+
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+func (writer *compressWriter) WriteHeader(status int) {
+	if writer.decided || writer.status != 0 {
+		return
+	}
+
+	writer.status = status
+
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		writer.raw()
+	}
+}
+
+func (writer *compressWriter) Write(data []byte) (int, error) {
+	if writer.status == 0 {
+		writer.status = http.StatusOK
+	}
+
+	if writer.gzip != nil {
+		return writer.gzip.Write(data)
+	} else if writer.decided {
+		return writer.ResponseWriter.Write(data)
+	}
+
+	writer.buffer = append(writer.buffer, data...)
+
+	if len(writer.buffer) >= minimumCompressed {
+		if err := writer.start(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(data), nil
+}
+
+func (writer *compressWriter) start() error {
+	var header = writer.Header()
+
+	if header.Get("Content-Encoding") != "" {
+		return writer.raw()
+	}
+
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", http.DetectContentType(writer.buffer))
+	}
+
+	var compressed, err = gzip.NewWriterLevel(writer.ResponseWriter, writer.level)
+
+	if err != nil {
+		return err
+	}
+
+	header.Set("Content-Encoding", "gzip")
+	header.Del("Content-Length")
+
+	writer.gzip, writer.decided = compressed, true
+	writer.ResponseWriter.WriteHeader(writer.status)
+
+	_, err = writer.gzip.Write(writer.buffer)
+	writer.buffer = nil
+
+	return err
+}
+
+func (writer *compressWriter) raw() error {
+	writer.decided = true
+
+	if writer.status != 0 {
+		writer.ResponseWriter.WriteHeader(writer.status)
+	}
+
+	if len(writer.buffer) == 0 {
+		return nil
+	}
+
+	var _, err = writer.ResponseWriter.Write(writer.buffer)
+	writer.buffer = nil
+
+	return err
+}
+
+func (writer *compressWriter) Flush() {
+	if writer.status == 0 {
+		writer.status = http.StatusOK
+	}
+
+	if !writer.decided {
+		writer.start()
+	}
+
+	if writer.gzip != nil {
+		writer.gzip.Flush()
+	}
+
+	if flusher, ok := writer.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (writer *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := writer.ResponseWriter.(http.Hijacker); ok {
+		writer.decided = true
+		return hijacker.Hijack()
+	}
+
+	return nil, nil, errors.New("Hijacking not supported!")
+}
+
+func (writer *compressWriter) Unwrap() http.ResponseWriter {
+	return writer.ResponseWriter
+}
+
+func (writer *compressWriter) close() {
+	if writer.gzip != nil {
+		writer.gzip.Close()
+	} else if !writer.decided {
+		writer.raw()
+	}
+}
@@ -0,0 +1,99 @@
+package routes
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompress(test *testing.T) {
+	var body = strings.Repeat("hello, world! ", 100)
+	var router = New()
+	var status int
+
+	router.Use(Logger(func(entry LogEntry) {
+		status = entry.Status
+	}), Compress(gzip.BestSpeed))
+	router.Add("/large", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(body))
+	}), "large")
+	router.Add("/tiny", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tiny"))
+	}), "tiny")
+	router.Add("/encoded", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "br")
+		w.Write([]byte(body))
+	}), "encoded")
+
+	var request = httptest.NewRequest(http.MethodGet, "/large", nil)
+	request.Header.Set("Accept-Encoding", "br;q=0.5, gzip")
+
+	var response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+
+	if response.Code != http.StatusCreated || status != http.StatusCreated {
+		test.Fatalf("Expected %d, got %d (logged %d)", http.StatusCreated, response.Code, status)
+	} else if response.Header().Get("Content-Encoding") != "gzip" || response.Header().Get("Vary") != "Accept-Encoding" {
+		test.Fatalf("Unexpected headers: %v", response.Header())
+	} else if response.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		test.Fatalf("Unexpected Content-Type: %s", response.Header().Get("Content-Type"))
+	}
+
+	if reader, err := gzip.NewReader(response.Body); err != nil {
+		test.Fatal(err)
+	} else if data, err := io.ReadAll(reader); err != nil {
+		test.Fatal(err)
+	} else if string(data) != body {
+		test.Fatalf("Unexpected body: %s", data)
+	}
+
+	for path, encoding := range map[string]string{"/tiny": "", "/encoded": "br"} {
+		var request = httptest.NewRequest(http.MethodGet, path, nil)
+		request.Header.Set("Accept-Encoding", "gzip")
+
+		var response = httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		if response.Header().Get("Content-Encoding") != encoding || strings.Contains(response.Body.String(), "\x1f\x8b") {
+			test.Fatalf("Expected uncompressed response for %s", path)
+		}
+	}
+
+	request = httptest.NewRequest(http.MethodGet, "/large", nil)
+	request.Header.Set("Accept-Encoding", "gzip;q=0, *")
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+
+	if response.Header().Get("Content-Encoding") != "" || response.Body.String() != body {
+		test.Fatal("Expected uncompressed response when gzip is refused!")
+	}
+}
+
+func TestCompressFlush(test *testing.T) {
+	var handler = Compress(gzip.DefaultCompression)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("chunk"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("chunk"))
+	}))
+
+	var request = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+
+	var response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	if !response.Flushed || response.Header().Get("Content-Encoding") != "gzip" {
+		test.Fatal("Expected compressed, flushed response!")
+	}
+
+	if reader, err := gzip.NewReader(response.Body); err != nil {
+		test.Fatal(err)
+	} else if data, _ := io.ReadAll(reader); string(data) != "chunkchunk" {
+		test.Fatalf("Unexpected body: %s", data)
+	}
+}
@@ -0,0 +1,72 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// Param returns the named path parameter. Parameters live under unexported
+// context keys, so they never shadow values from the request or server base
+// context, even when a parameter and a key share a name. Host labels captured
+// by a HostRouter count as the outermost parameters here and in Params, but
+// never shift the path parameters stored under Key.
+func Param(ctx context.Context, name string) string {
+	if m, ok := ctx.Value(matchKey).(*match); ok {
+		for index, n := range m.hostNames {
+			if n == name {
+				return m.hostLabels[index]
+			}
+		}
+
+		for index, n := range m.names {
+			if n == name && index < len(m.parameters) {
+				return m.parameters[index]
+			}
+		}
+	}
+
+	return ""
+}
+
+func Params(ctx context.Context) map[string]string {
+	if m, ok := ctx.Value(matchKey).(*match); ok {
+		return m.values()
+	}
+
+	return make(map[string]string)
+}
+
+func ParamInt(ctx context.Context, name string) (int, error) {
+	if value := Param(ctx, name); value == "" {
+		return 0, fmt.Errorf("Parameter '%s' not found!", name)
+	} else if number, err := strconv.Atoi(value); err != nil {
+		return 0, fmt.Errorf("Parameter '%s' is not an integer!", name)
+	} else {
+		return number, nil
+	}
+}
+
+func MatchedRoute(ctx context.Context) *Route {
+	if m, ok := ctx.Value(matchKey).(*match); ok {
+		return m.route
+	}
+
+	return nil
+}
+
+func MatchedNamespace(ctx context.Context) string {
+	if m, ok := ctx.Value(matchKey).(*match); ok {
+		return m.namespace()
+	}
+
+	return ""
+}
+
+func Pattern(ctx context.Context) string {
+	if m, ok := ctx.Value(matchKey).(*match); ok && m.route != nil {
+		return m.pattern()
+	}
+
+	return ""
+}
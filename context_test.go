@@ -0,0 +1,230 @@
+package routes
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParam(test *testing.T) {
+	var tenant, name string
+	var number int
+	var missing, invalid error
+
+	var api = New()
+	api.Add("/users/:id/:name", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, name = Param(r.Context(), "tenant"), Param(r.Context(), "name")
+		number, _ = ParamInt(r.Context(), "id")
+		_, missing = ParamInt(r.Context(), "missing")
+		_, invalid = ParamInt(r.Context(), "name")
+	}), "user")
+
+	var router = New()
+	router.AddRouter("/:tenant", api, "api")
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/acme/users/42/john", nil))
+
+	if tenant != "acme" || name != "john" || number != 42 {
+		test.Fatalf("Unexpected parameters: %s, %s, %d", tenant, name, number)
+	}
+
+	if missing == nil {
+		test.Fatal("Expected error for missing parameter!")
+	}
+
+	if invalid == nil {
+		test.Fatal("Expected error for non-numeric parameter!")
+	}
+}
+
+func TestParams(test *testing.T) {
+	var parameters map[string]string
+	var router = New()
+
+	router.Add("/:tenant/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parameters = Params(r.Context())
+	}), "user")
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/acme/users/5", nil))
+
+	if expected := map[string]string{"tenant": "acme", "id": "5"}; !reflect.DeepEqual(parameters, expected) {
+		test.Fatalf("Unexpected parameters: %v", parameters)
+	}
+
+	if parameters = Params(httptest.NewRequest(http.MethodGet, "/", nil).Context()); parameters == nil || len(parameters) != 0 {
+		test.Fatalf("Expected empty parameters, got %v", parameters)
+	}
+}
+
+func TestMatchedRoute(test *testing.T) {
+	var scope interface{}
+	var router = New()
+
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route := MatchedRoute(r.Context()); route != nil {
+				scope, _ = route.Meta("scope")
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	var route = router.Add("/admin", &Handler{}, "admin").SetMeta("scope", "admin")
+	var meta = route.meta
+
+	route.SetMeta("summary", "Administration")
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/admin", nil))
+
+	if scope != "admin" {
+		test.Fatalf("Expected admin scope, got %v", scope)
+	}
+
+	if _, ok := meta["summary"]; ok {
+		test.Fatal("Expected metadata to be copied on write!")
+	}
+
+	if MatchedRoute(httptest.NewRequest(http.MethodGet, "/", nil).Context()) != nil {
+		test.Fatal("Expected no route!")
+	}
+}
+
+func TestMatchedNamespace(test *testing.T) {
+	var namespace string
+	var handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		namespace = MatchedNamespace(r.Context())
+	})
+
+	var users = New()
+	users.Add("/:id", handler, "detail")
+
+	var api = New()
+	api.AddRouter("/users", users, "users")
+	api.Add("/status", handler, "status")
+
+	var router = New(WithCache(8))
+	router.AddRouter("/posts", New(), "posts")
+	router.AddRouter("/api", api, "api")
+	router.Add("/", handler, "home")
+
+	var tests = map[string]string{
+		"/api/users/1": "api:users",
+		"/api/status":  "api",
+		"/":            "",
+	}
+
+	for index := 0; index < 2; index++ {
+		for path, expected := range tests {
+			namespace = "unset"
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+
+			var m, ok = router.Match(httptest.NewRequest(http.MethodGet, path, nil))
+
+			if namespace != expected {
+				test.Fatalf("Expected %q for %s, got %q", expected, path, namespace)
+			} else if !ok || m.Namespace != expected {
+				test.Fatalf("Expected %q for %s, got %+v", expected, path, m)
+			} else if _, err := router.Reverse(qualify(m.Namespace, m.Route.Name()), "1"); err != nil {
+				test.Fatalf("Expected %s to reverse, got %v", path, err)
+			}
+		}
+	}
+
+	if MatchedNamespace(context.Background()) != "" {
+		test.Fatal("Expected no namespace!")
+	}
+}
+
+func TestPattern(test *testing.T) {
+	var patterns = make([]string, 0)
+	var handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		patterns = append(patterns, Pattern(r.Context()))
+	})
+
+	var v1 = New()
+	v1.Add("/users/:id", handler, "user")
+	v1.Add("/", handler, "index")
+
+	var api = New()
+	api.AddRouter("/v1", v1, "v1")
+
+	var router = New()
+	router.Add("/", handler, "index")
+	router.AddRouter("/api", api, "api")
+
+	for _, path := range []string{"/api/v1/users/5", "/api/v1", "/"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if expected := []string{"/api/v1/users/:id", "/api/v1", "/"}; !reflect.DeepEqual(patterns, expected) {
+		test.Error("Incorrect patterns!")
+		test.Errorf("Expected: %v", expected)
+		test.Fatalf("Got: %v", patterns)
+	}
+
+	if pattern := Pattern(httptest.NewRequest(http.MethodGet, "/", nil).Context()); pattern != "" {
+		test.Fatalf("Expected empty pattern, got %s", pattern)
+	}
+}
+
+func TestParamKeyCollision(test *testing.T) {
+	type userKey string
+
+	var user, param string
+	var router = New()
+
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var ctx = context.WithValue(r.Context(), "user", "logger")
+			ctx = context.WithValue(ctx, userKey("user"), "logger")
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	router.Add("/users/:user", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ = r.Context().Value(userKey("user")).(string)
+		param = Param(r.Context(), "user")
+	}), "user")
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/john", nil))
+
+	if user != "logger" || param != "john" {
+		test.Fatalf("Unexpected values: %s, %s", user, param)
+	}
+}
+
+func TestBaseContext(test *testing.T) {
+	type configKey string
+
+	var values = make(chan [3]string, 2)
+	var router = New()
+
+	router.Add("/pools/:db", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var db, _ = r.Context().Value("db").(string)
+		var config, _ = r.Context().Value(configKey("config")).(string)
+		values <- [3]string{db, config, Param(r.Context(), "db")}
+	}), "pool")
+
+	var server = httptest.NewUnstartedServer(router)
+	server.Config.BaseContext = func(net.Listener) context.Context {
+		var ctx = context.WithValue(context.Background(), "db", "primary")
+		return context.WithValue(ctx, configKey("config"), "production")
+	}
+	server.Start()
+	defer server.Close()
+
+	for _, pool := range []string{"replica", "analytics"} {
+		if response, err := http.Get(server.URL + "/pools/" + pool); err != nil {
+			test.Fatal(err)
+		} else {
+			response.Body.Close()
+		}
+
+		if expected, got := [3]string{"primary", "production", pool}, <-values; got != expected {
+			test.Fatalf("Expected %v, got %v", expected, got)
+		}
+	}
+}
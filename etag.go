@@ -0,0 +1,144 @@
+package routes
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const maximumTagged = 1 << 20
+
+type etagWriter struct {
+	http.ResponseWriter
+
+	status    int
+	buffer    []byte
+	streaming bool
+}
+
+func (writer *etagWriter) WriteHeader(status int) {
+	if writer.streaming {
+		writer.ResponseWriter.WriteHeader(status)
+		return
+	} else if writer.status != 0 {
+		return
+	}
+
+	if writer.status = status; status != http.StatusOK {
+		writer.stream()
+	}
+}
+
+func (writer *etagWriter) Write(data []byte) (int, error) {
+	if writer.status == 0 {
+		writer.status = http.StatusOK
+	}
+
+	if !writer.streaming && len(writer.buffer)+len(data) > maximumTagged {
+		if err := writer.stream(); err != nil {
+			return 0, err
+		}
+	}
+
+	if writer.streaming {
+		return writer.ResponseWriter.Write(data)
+	}
+
+	writer.buffer = append(writer.buffer, data...)
+
+	return len(data), nil
+}
+
+func (writer *etagWriter) stream() error {
+	if writer.streaming {
+		return nil
+	} else if writer.status == 0 {
+		writer.status = http.StatusOK
+	}
+
+	writer.streaming = true
+	writer.ResponseWriter.WriteHeader(writer.status)
+
+	if len(writer.buffer) == 0 {
+		return nil
+	}
+
+	var _, err = writer.ResponseWriter.Write(writer.buffer)
+	writer.buffer = nil
+
+	return err
+}
+
+func (writer *etagWriter) Flush() {
+	writer.stream()
+
+	if flusher, ok := writer.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (writer *etagWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := writer.ResponseWriter.(http.Hijacker); ok {
+		writer.streaming = true
+		return hijacker.Hijack()
+	}
+
+	return nil, nil, errors.New("Hijacking not supported!")
+}
+
+func (writer *etagWriter) Unwrap() http.ResponseWriter {
+	return writer.ResponseWriter
+}
+
+func fresh(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		if candidate = strings.TrimSpace(candidate); candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (writer *etagWriter) finish(request *http.Request) {
+	if writer.streaming {
+		return
+	}
+
+	var header = writer.ResponseWriter.Header()
+	var tag = header.Get("ETag")
+
+	if tag == "" {
+		var sum = sha1.Sum(writer.buffer)
+		tag = "\"" + hex.EncodeToString(sum[:]) + "\""
+		header.Set("ETag", tag)
+	}
+
+	if match := request.Header.Get("If-None-Match"); match != "" && fresh(match, tag) {
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		writer.ResponseWriter.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	writer.stream()
+}
+
+func ETag() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			if request.Method != http.MethodGet && request.Method != http.MethodHead {
+				next.ServeHTTP(response, request)
+				return
+			}
+
+			var writer = &etagWriter{ResponseWriter: response}
+			next.ServeHTTP(writer, request)
+			writer.finish(request)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestETag(test *testing.T) {
+	var router = New()
+	router.Use(ETag())
+	router.Add("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"users":[]}`))
+	}), "users", http.MethodGet, http.MethodPost)
+	router.Add("/tagged", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("tagged"))
+	}), "tagged")
+	router.Add("/missing", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}), "missing")
+
+	var serve = func(method, path, match string) *httptest.ResponseRecorder {
+		var response = httptest.NewRecorder()
+		var request = httptest.NewRequest(method, path, nil)
+
+		if match != "" {
+			request.Header.Set("If-None-Match", match)
+		}
+
+		router.ServeHTTP(response, request)
+
+		return response
+	}
+
+	var response = serve(http.MethodGet, "/users", "")
+	var tag = response.Header().Get("ETag")
+
+	if response.Code != http.StatusOK || response.Body.String() != `{"users":[]}` || !strings.HasPrefix(tag, `"`) {
+		test.Fatalf("Unexpected response: %d %s %s", response.Code, tag, response.Body.String())
+	}
+
+	var tests = []struct {
+		method   string
+		path     string
+		match    string
+		expected int
+	}{
+		{http.MethodGet, "/users", tag, http.StatusNotModified},
+		{http.MethodGet, "/users", `"other", W/` + tag, http.StatusNotModified},
+		{http.MethodGet, "/users", `"other"`, http.StatusOK},
+		{http.MethodPost, "/users", tag, http.StatusOK},
+		{http.MethodGet, "/tagged", `"v1"`, http.StatusNotModified},
+		{http.MethodGet, "/tagged", "*", http.StatusNotModified},
+		{http.MethodGet, "/missing", "*", http.StatusNotFound},
+	}
+
+	for _, t := range tests {
+		if response := serve(t.method, t.path, t.match); response.Code != t.expected {
+			test.Fatalf("Expected %d for %s %s with %s, got %d", t.expected, t.method, t.path, t.match, response.Code)
+		} else if t.expected == http.StatusNotModified && response.Body.Len() != 0 {
+			test.Fatalf("Expected an empty body, got %q", response.Body.String())
+		}
+	}
+
+	if response := serve(http.MethodPost, "/users", ""); response.Header().Get("ETag") != "" {
+		test.Fatal("Expected no ETag for unsafe methods!")
+	}
+}
+
+func TestETagStreaming(test *testing.T) {
+	var flushed bool
+	var response = httptest.NewRecorder()
+
+	ETag()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+		flushed = response.Flushed
+	})).ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !flushed || response.Body.String() != "data: 1\n\n" {
+		test.Fatalf("Expected the flush to pass through, got %q", response.Body.String())
+	} else if response.Header().Get("ETag") != "" {
+		test.Fatal("Expected no ETag on a streamed response!")
+	}
+
+	response = httptest.NewRecorder()
+	ETag()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, maximumTagged+1))
+	})).ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if response.Body.Len() != maximumTagged+1 || response.Header().Get("ETag") != "" {
+		test.Fatalf("Expected an untagged large body, got %d bytes", response.Body.Len())
+	}
+}
@@ -1 +1,3 @@
 module github.com/govenant/routes
+
+go 1.22
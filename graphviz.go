@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+func (router *Router) Graphviz() string {
+	var buffer strings.Builder
+	var counter = 0
+
+	router.mutex.RLock()
+	defer router.mutex.RUnlock()
+
+	buffer.WriteString("digraph routes {\n")
+	router.graphviz(&buffer, "", "/", &counter, 1)
+	buffer.WriteString("}\n")
+
+	return buffer.String()
+}
+
+func (router *Router) trie() []*Router {
+	var nodes = []*Router{router}
+	var keys = make([]string, 0, len(router.nodes))
+
+	for key := range router.nodes {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		nodes = append(nodes, router.nodes[key].trie()...)
+	}
+
+	return nodes
+}
+
+func (router *Router) graphviz(buffer *strings.Builder, namespace, prefix string, counter *int, depth int) string {
+	var id = fmt.Sprintf("router%d", *counter)
+	var indent = strings.Repeat("\t", depth)
+	var label = namespace
+
+	if label == "" {
+		label = "root"
+	}
+
+	*counter++
+
+	fmt.Fprintf(buffer, "%ssubgraph cluster_%s {\n", indent, id)
+	fmt.Fprintf(buffer, "%s\tlabel=%q;\n", indent, label)
+	fmt.Fprintf(buffer, "%s\t%s [shape=box, label=%q];\n", indent, id, prefix)
+
+	var nodes = router.trie()
+
+	for _, node := range nodes {
+		for _, route := range node.routes {
+			if route.depth != node.depth {
+				continue
+			}
+
+			var name = fmt.Sprintf("route%d", *counter)
+			var methods = "*"
+
+			if len(route.Methods) > 0 {
+				methods = strings.Join(route.Methods, ", ")
+			}
+
+			*counter++
+
+			fmt.Fprintf(buffer, "%s\t%s [label=%q];\n", indent, name, join(prefix, route.pattern)+"\n"+methods)
+			fmt.Fprintf(buffer, "%s\t%s -> %s;\n", indent, id, name)
+		}
+	}
+
+	for _, node := range nodes {
+		for _, mount := range node.mounts {
+			var child = mount.graphviz(buffer, qualify(namespace, mount.name), join(prefix, mount.pattern), counter, depth+1)
+			fmt.Fprintf(buffer, "%s\t%s -> %s;\n", indent, id, child)
+		}
+	}
+
+	fmt.Fprintf(buffer, "%s}\n", indent)
+
+	return id
+}
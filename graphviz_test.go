@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestGraphviz(test *testing.T) {
+	var v1 = New()
+	v1.Add("/users/:id", &Handler{}, "user", http.MethodGet, http.MethodPut)
+
+	var api = New()
+	api.Add("/health", &Handler{}, "health")
+	api.AddRouter("/v1", v1, "v1")
+
+	var router = New()
+	router.Add("/", &Handler{}, "home", http.MethodGet)
+	router.AddRouter("/api", api, "api")
+
+	var expected, err = os.ReadFile("testdata/routes.dot")
+
+	if err != nil {
+		test.Fatal(err)
+	} else if dot := router.Graphviz(); dot != string(expected) {
+		test.Fatalf("Expected:\n%s\nGot:\n%s", expected, dot)
+	}
+}
@@ -0,0 +1,121 @@
+package routes
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type host struct {
+	pattern string
+	router  *Router
+}
+
+type HostRouter struct {
+	NotFoundHandler http.Handler
+	Strict          bool
+
+	hosts []*host
+}
+
+func digits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return value != ""
+}
+
+func hostport(address string) (string, string) {
+	if net.ParseIP(address) != nil {
+		return address, ""
+	} else if index := strings.LastIndexByte(address, ':'); index != -1 && digits(address[index+1:]) {
+		return strings.TrimSuffix(strings.TrimPrefix(address[:index], "["), "]"), address[index+1:]
+	}
+
+	return strings.TrimSuffix(strings.TrimPrefix(address, "["), "]"), ""
+}
+
+func (h *host) match(address string, strict bool) ([]string, []string, bool) {
+	var original, port = hostport(address)
+	var pattern, expected = hostport(h.pattern)
+	var name, lower = strings.ToLower(original), strings.ToLower(pattern)
+
+	if h.pattern == "" {
+		return nil, nil, true
+	} else if strict && port != expected {
+		return nil, nil, false
+	} else if pattern == "" {
+		return nil, nil, true
+	} else if ip := net.ParseIP(pattern); ip != nil {
+		return nil, nil, ip.Equal(net.ParseIP(name))
+	} else if pattern[0] != GreedyParameterRune {
+		return labels(original, pattern)
+	} else if suffix := lower[1:]; len(name) > len(suffix) && strings.HasSuffix(name, suffix) {
+		return []string{"subdomain"}, []string{original[:len(name)-len(suffix)]}, true
+	}
+
+	return nil, nil, false
+}
+
+func labels(name, pattern string) ([]string, []string, bool) {
+	var names, parameters []string
+	var parts, keys = strings.Split(name, "."), strings.Split(pattern, ".")
+
+	if len(parts) != len(keys) {
+		return nil, nil, false
+	}
+
+	for index, key := range keys {
+		if key != "" && key[0] == ParameterRune && parts[index] != "" {
+			names = append(names, key[1:])
+			parameters = append(parameters, parts[index])
+		} else if !strings.EqualFold(key, parts[index]) {
+			return nil, nil, false
+		}
+	}
+
+	return names, parameters, true
+}
+
+func (router *HostRouter) Add(pattern string, node *Router) {
+	router.hosts = append(router.hosts, &host{pattern: pattern, router: node})
+}
+
+func (router *HostRouter) Resolve(address string) (*Router, []string) {
+	for _, host := range router.hosts {
+		if _, parameters, ok := host.match(address, router.Strict); ok {
+			return host.router, parameters
+		}
+	}
+
+	return nil, nil
+}
+
+func (router *HostRouter) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	for _, host := range router.hosts {
+		if names, parameters, ok := host.match(request.Host, router.Strict); ok {
+			var ctx = request.Context()
+
+			if len(parameters) > 0 {
+				ctx = context.WithValue(ctx, matchKey, &match{hostNames: names, hostLabels: parameters})
+			}
+
+			host.router.ServeHTTP(response, request.WithContext(ctx))
+			return
+		}
+	}
+
+	if router.NotFoundHandler != nil {
+		router.NotFoundHandler.ServeHTTP(response, request)
+	} else {
+		response.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func NewHostRouter() *HostRouter {
+	return &HostRouter{}
+}
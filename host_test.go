@@ -0,0 +1,206 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHostRouter(test *testing.T) {
+	var served string
+	var handler = func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served = name + ":" + Param(r.Context(), "subdomain") + ":" + Param(r.Context(), "id")
+		})
+	}
+
+	var api = New()
+	api.Add("/users/:id", handler("api"), "user")
+
+	var tenants = New()
+	tenants.Add("/users/:id", handler("tenants"), "user")
+
+	var fallback = New()
+	fallback.Add("/users/:id", handler("fallback"), "user")
+
+	var router = NewHostRouter()
+	router.Add("api.example.com", api)
+	router.Add("*.example.com", tenants)
+	router.Add("", fallback)
+
+	var tests = map[string]string{
+		"api.example.com":      "api::1",
+		"API.example.com:8080": "api::1",
+		"acme.example.com":     "tenants:acme:1",
+		"a.b.example.com":      "tenants:a.b:1",
+		"example.com":          "fallback::1",
+		"other.org":            "fallback::1",
+	}
+
+	for host, expected := range tests {
+		var request = httptest.NewRequest(http.MethodGet, "/users/1", nil)
+		request.Host = host
+		served = ""
+
+		router.ServeHTTP(httptest.NewRecorder(), request)
+
+		if served != expected {
+			test.Errorf("Test '%s' failed!", host)
+			test.Errorf("Expected: %s", expected)
+			test.Fatalf("Got: %s", served)
+		}
+	}
+}
+
+func TestHostRouterPorts(test *testing.T) {
+	var served string
+	var handler = func(name string) *Router {
+		var router = New()
+		router.Add("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served = name
+		}), "root")
+
+		return router
+	}
+
+	var router = NewHostRouter()
+	router.Add("example.com", handler("bare"))
+	router.Add("admin.example.com:8443", handler("admin"))
+	router.Add("[::1]:8080", handler("loopback"))
+	router.Add("", handler("fallback"))
+
+	var tests = []struct {
+		host     string
+		strict   bool
+		expected string
+	}{
+		{"example.com", false, "bare"},
+		{"example.com:8080", false, "bare"},
+		{"admin.example.com", false, "admin"},
+		{"[::1]", false, "loopback"},
+		{"[0:0::1]:9000", false, "loopback"},
+		{"example.com", true, "bare"},
+		{"example.com:8080", true, "fallback"},
+		{"admin.example.com", true, "fallback"},
+		{"admin.example.com:8443", true, "admin"},
+		{"[::1]:8080", true, "loopback"},
+		{"[::1]:9000", true, "fallback"},
+	}
+
+	for _, t := range tests {
+		var request = httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Host = t.host
+		router.Strict, served = t.strict, ""
+
+		router.ServeHTTP(httptest.NewRecorder(), request)
+
+		if served != t.expected {
+			test.Fatalf("Expected %s for %s (strict %v), got %s", t.expected, t.host, t.strict, served)
+		}
+	}
+}
+
+func TestHostRouterPortOnly(test *testing.T) {
+	var router = NewHostRouter()
+	router.Add(":8080", New())
+	router.Strict = true
+
+	var tests = map[string]bool{
+		"example.com:8080": true,
+		"[::1]:8080":       true,
+		"example.com:9000": false,
+		"example.com":      false,
+	}
+
+	for address, expected := range tests {
+		if node, _ := router.Resolve(address); (node != nil) != expected {
+			test.Fatalf("Expected %v for %s", expected, address)
+		}
+	}
+
+	var response = httptest.NewRecorder()
+	var request = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Host = "example.com:9000"
+
+	router.ServeHTTP(response, request)
+
+	if response.Code != http.StatusNotFound {
+		test.Fatalf("Expected %d, got %d", http.StatusNotFound, response.Code)
+	}
+}
+
+func TestHostRouterParameters(test *testing.T) {
+	var tenant, region string
+
+	var api = New()
+	api.Add("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, region = Param(r.Context(), "tenant"), Param(r.Context(), "region")
+	}), "user")
+
+	var router = NewHostRouter()
+	router.Add(":tenant.:region.example.com", api)
+	router.Add(":tenant.example.com", api)
+
+	var tests = map[string][2]string{
+		"acme.example.com:8080":    {"acme", ""},
+		"acme.eu.example.com":      {"acme", "eu"},
+		"Acme.EXAMPLE.com":         {"Acme", ""},
+		"[acme.example.com]:8080":  {"acme", ""},
+		"acme.eu.west.example.com": {"", ""},
+	}
+
+	for host, expected := range tests {
+		var request = httptest.NewRequest(http.MethodGet, "/users/1", nil)
+		request.Host = host
+		tenant, region = "", ""
+
+		router.ServeHTTP(httptest.NewRecorder(), request)
+
+		if tenant != expected[0] || region != expected[1] {
+			test.Fatalf("Expected %v for %s, got [%s %s]", expected, host, tenant, region)
+		}
+	}
+
+	if node, parameters := router.Resolve("acme.example.com:8080"); node != api || len(parameters) != 1 || parameters[0] != "acme" {
+		test.Fatalf("Unexpected resolution: %v", parameters)
+	}
+}
+
+func TestHostRouterNotFound(test *testing.T) {
+	var router = NewHostRouter()
+	router.Add("api.example.com", New())
+
+	var request = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Host = "www.example.com"
+
+	var response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+
+	if response.Code != http.StatusNotFound {
+		test.Fatalf("Expected %d, got %d", http.StatusNotFound, response.Code)
+	}
+}
+
+func TestHostRouterKeepsPathParameters(test *testing.T) {
+	var positional []string
+	var named map[string]string
+
+	var api = New()
+	api.Add("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		positional, named = r.Context().Value(Key).([]string), Params(r.Context())
+	}), "user")
+
+	var router = NewHostRouter()
+	router.Add(":tenant.example.com", api)
+
+	var request = httptest.NewRequest(http.MethodGet, "/users/5", nil)
+	request.Host = "acme.example.com"
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	if !reflect.DeepEqual(positional, []string{"5"}) {
+		test.Fatalf("Expected [5], got %v", positional)
+	} else if expected := map[string]string{"tenant": "acme", "id": "5"}; !reflect.DeepEqual(named, expected) {
+		test.Fatalf("Expected %v, got %v", expected, named)
+	}
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func JSON(status int, body interface{}) http.Handler {
+	var data, err = json.Marshal(body)
+
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(status)
+		response.Write(data)
+	})
+}
+
+func JSONNotFound(body interface{}) http.Handler {
+	return JSON(http.StatusNotFound, body)
+}
+
+func JSONMethodNotAllowed(body interface{}) http.Handler {
+	return JSON(http.StatusMethodNotAllowed, body)
+}
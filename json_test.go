@@ -0,0 +1,64 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJSONErrors(test *testing.T) {
+	var router = New(
+		WithNotFoundHandler(JSONNotFound(map[string]string{"error": "not found"})),
+		WithMethodNotAllowedHandler(JSONMethodNotAllowed(map[string]string{"error": "method not allowed"})),
+	)
+	router.GET("/users", &Handler{})
+
+	var tests = []struct {
+		method  string
+		path    string
+		status  int
+		body    string
+		allowed string
+	}{
+		{http.MethodGet, "/missing", http.StatusNotFound, `{"error":"not found"}`, ""},
+		{http.MethodPost, "/users", http.StatusMethodNotAllowed, `{"error":"method not allowed"}`, http.MethodGet},
+	}
+
+	for _, t := range tests {
+		var response = httptest.NewRecorder()
+		router.ServeHTTP(response, httptest.NewRequest(t.method, t.path, nil))
+
+		if response.Code != t.status {
+			test.Fatalf("Expected %d, got %d", t.status, response.Code)
+		} else if contentType := response.Header().Get("Content-Type"); contentType != "application/json" {
+			test.Fatalf("Expected application/json, got %s", contentType)
+		} else if body := response.Body.String(); body != t.body {
+			test.Fatalf("Expected %s, got %s", t.body, body)
+		} else if allowed := response.Header().Get("Allow"); allowed != t.allowed {
+			test.Fatalf("Expected Allow %q, got %q", t.allowed, allowed)
+		}
+	}
+}
+
+func TestJSONMethodNotAllowedFailedMount(test *testing.T) {
+	var api = New(WithMethodNotAllowedHandler(JSONMethodNotAllowed(map[string]string{"error": "api"})))
+	api.GET("/users", &Handler{})
+
+	var router = New()
+	router.AddRouter("/api", api, "api")
+	router.GET("/*rest", &Handler{})
+
+	var response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodPost, "/api/missing", nil))
+
+	if response.Code != http.StatusMethodNotAllowed || response.Body.Len() != 0 {
+		test.Fatalf("Expected a plain %d, got %d %s", http.StatusMethodNotAllowed, response.Code, response.Body.String())
+	}
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodPost, "/api/users", nil))
+
+	if response.Body.String() != `{"error":"api"}` {
+		test.Fatalf("Expected the api handler, got %s", response.Body.String())
+	}
+}
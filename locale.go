@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const localeKey = key("locale")
+
+type LocaleRouter struct {
+	Locales  []string
+	Default  string
+	Redirect bool
+
+	router *Router
+}
+
+func NewLocaleRouter(router *Router, fallback string, locales ...string) *LocaleRouter {
+	return &LocaleRouter{Locales: locales, Default: fallback, router: router}
+}
+
+func Locale(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey).(string); ok {
+		return locale
+	}
+
+	return ""
+}
+
+func (router *LocaleRouter) locale(part string) (string, bool) {
+	for _, locale := range router.Locales {
+		if strings.EqualFold(locale, part) {
+			return locale, true
+		}
+	}
+
+	return "", false
+}
+
+func (router *LocaleRouter) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	var path = strings.TrimPrefix(request.URL.Path, "/")
+	var part, rest = path, ""
+
+	if index := strings.IndexRune(path, '/'); index != -1 {
+		part, rest = path[:index], path[index:]
+	}
+
+	var locale, ok = router.locale(part)
+
+	if !ok && router.Redirect && (request.Method == http.MethodGet || request.Method == http.MethodHead) {
+		var location = stripped(request) + "/" + router.Default + request.URL.Path
+
+		if request.URL.RawQuery != "" {
+			location = location + "?" + request.URL.RawQuery
+		}
+
+		http.Redirect(response, request, location, http.StatusFound)
+		return
+	} else if !ok {
+		locale, rest = router.Default, request.URL.Path
+	}
+
+	if rest == "" {
+		rest = "/"
+	}
+
+	var location = new(url.URL)
+	*location = *request.URL
+	location.Path = rest
+	location.RawPath = ""
+
+	var ctx = context.WithValue(request.Context(), localeKey, locale)
+
+	if ok {
+		ctx = context.WithValue(ctx, prefixKey, stripped(request)+"/"+part)
+	}
+
+	var inner = request.WithContext(ctx)
+	inner.URL = location
+
+	router.router.ServeHTTP(response, inner)
+}
+
+func (router *LocaleRouter) Reverse(ctx context.Context, name string, parameters ...string) (string, error) {
+	var locale = Locale(ctx)
+
+	if locale == "" {
+		locale = router.Default
+	}
+
+	if path, err := router.router.Reverse(name, parameters...); err != nil {
+		return "", err
+	} else if path == "/" {
+		return "/" + locale, nil
+	} else {
+		return "/" + locale + path, nil
+	}
+}
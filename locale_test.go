@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLocaleRouter(test *testing.T) {
+	var served, link string
+	var router = New()
+
+	var locales = NewLocaleRouter(router, "en", "en", "de")
+
+	router.Add("/products/:id?", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served = Locale(r.Context()) + ":" + Param(r.Context(), "id")
+		link, _ = locales.Reverse(r.Context(), "products", "5")
+	}), "products")
+
+	var tests = map[string][2]string{
+		"/de/products":   {"de:", "/de/products/5"},
+		"/EN/products/1": {"en:1", "/en/products/5"},
+		"/products/2":    {"en:2", "/en/products/5"},
+	}
+
+	for path, expected := range tests {
+		served, link = "", ""
+		locales.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+
+		if served != expected[0] || link != expected[1] {
+			test.Fatalf("Expected %v for %s, got [%s %s]", expected, path, served, link)
+		}
+	}
+
+	locales.Redirect = true
+
+	var response = httptest.NewRecorder()
+	locales.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/products?page=2", nil))
+
+	if response.Code != http.StatusFound || response.Header().Get("Location") != "/en/products?page=2" {
+		test.Fatalf("Unexpected redirect: %d %s", response.Code, response.Header().Get("Location"))
+	}
+}
+
+func TestLocaleRouterRedirects(test *testing.T) {
+	var router = New(WithRedirectTrailingSlash(), WithCleanPath())
+	router.Add("/products/:id?", &Handler{}, "products")
+
+	var locales = NewLocaleRouter(router, "en", "en", "de")
+
+	var tests = map[string]string{
+		"/de/products/":   "/de/products",
+		"/de/products//1": "/de/products/1",
+		"/products/":      "/products",
+	}
+
+	for path, expected := range tests {
+		var response = httptest.NewRecorder()
+		locales.ServeHTTP(response, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if response.Code != http.StatusMovedPermanently || response.Header().Get("Location") != expected {
+			test.Fatalf("Expected a redirect to %s for %s, got %d %s", expected, path, response.Code, response.Header().Get("Location"))
+		}
+	}
+
+	var outer = New()
+	outer.Mount("/shop", locales)
+
+	locales.Redirect = true
+
+	var response = httptest.NewRecorder()
+	outer.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/shop/products", nil))
+
+	if response.Code != http.StatusFound || response.Header().Get("Location") != "/shop/en/products" {
+		test.Fatalf("Unexpected redirect: %d %s", response.Code, response.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,28 @@
+package routes
+
+import "net/http"
+
+type Matcher interface {
+	Match(request *http.Request) bool
+}
+
+type MatcherFunc func(request *http.Request) bool
+
+func (fn MatcherFunc) Match(request *http.Request) bool {
+	return fn(request)
+}
+
+func MatchHeader(key, value string) Matcher {
+	return MatcherFunc(func(request *http.Request) bool {
+		if values, ok := request.Header[http.CanonicalHeaderKey(key)]; ok {
+			return value == "" || contains(values, value)
+		}
+
+		return false
+	})
+}
+
+func (route *Route) Require(matchers ...Matcher) *Route {
+	route.matchers = append(route.matchers[:len(route.matchers):len(route.matchers)], matchers...)
+	return route
+}
@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMatcher(test *testing.T) {
+	var served string
+	var handler = func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served = name
+		})
+	}
+
+	var router = New()
+	router.Add("/search", handler("beta"), "search.beta").Require(MatchHeader("X-Feature", "beta"))
+	router.Add("/search", handler("admin"), "search.admin").Require(MatcherFunc(func(r *http.Request) bool {
+		return r.Header.Get("X-Role") == "admin"
+	}))
+	router.Add("/search", handler("default"), "search")
+
+	var tests = map[string]map[string]string{
+		"beta":    {"X-Feature": "beta"},
+		"admin":   {"X-Feature": "alpha", "X-Role": "admin"},
+		"default": {},
+	}
+
+	for expected, headers := range tests {
+		var request = httptest.NewRequest(http.MethodGet, "/search", nil)
+
+		for key, value := range headers {
+			request.Header.Set(key, value)
+		}
+
+		served = ""
+		router.ServeHTTP(httptest.NewRecorder(), request)
+
+		if served != expected {
+			test.Fatalf("Expected %s, got %s", expected, served)
+		}
+	}
+
+	if conflicts := router.Validate(); len(conflicts) != 0 {
+		test.Fatalf("Unexpected conflicts: %v", conflicts)
+	}
+}
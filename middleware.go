@@ -0,0 +1,275 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CORSOptions struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int
+}
+
+func (options *CORSOptions) allows(origin string) bool {
+	for _, allowed := range options.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func CORS(options CORSOptions) func(http.Handler) http.Handler {
+	var methods = strings.Join(options.AllowedMethods, ", ")
+	var headers = strings.Join(options.AllowedHeaders, ", ")
+
+	if methods == "" {
+		methods = strings.Join([]string{http.MethodGet, http.MethodHead, http.MethodPost}, ", ")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			var origin = request.Header.Get("Origin")
+
+			if origin == "" || !options.allows(origin) {
+				next.ServeHTTP(response, request)
+				return
+			}
+
+			var header = response.Header()
+			header.Add("Vary", "Origin")
+
+			if options.AllowCredentials || !options.allows("*") {
+				header.Set("Access-Control-Allow-Origin", origin)
+			} else {
+				header.Set("Access-Control-Allow-Origin", "*")
+			}
+
+			if options.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if request.Method != http.MethodOptions || request.Header.Get("Access-Control-Request-Method") == "" {
+				next.ServeHTTP(response, request)
+				return
+			}
+
+			header.Set("Access-Control-Allow-Methods", methods)
+
+			if headers != "" {
+				header.Set("Access-Control-Allow-Headers", headers)
+			} else if requested := request.Header.Get("Access-Control-Request-Headers"); requested != "" {
+				header.Set("Access-Control-Allow-Headers", requested)
+			}
+
+			if options.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", strconv.Itoa(options.MaxAge))
+			}
+
+			response.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+func RequireContentType(types ...string) func(http.Handler) http.Handler {
+	return RequireContentTypeFor([]string{http.MethodPost, http.MethodPut, http.MethodPatch}, types...)
+}
+
+func RequireContentTypeFor(methods []string, types ...string) func(http.Handler) http.Handler {
+	var expected = strings.Join(types, ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			if !contains(methods, request.Method) {
+				next.ServeHTTP(response, request)
+				return
+			}
+
+			var header = request.Header.Get("Content-Type")
+
+			if media, _, err := mime.ParseMediaType(header); err == nil {
+				for _, allowed := range types {
+					if strings.EqualFold(media, allowed) {
+						next.ServeHTTP(response, request)
+						return
+					}
+				}
+			}
+
+			var message = fmt.Sprintf("Unsupported content type '%s', expected %s!", header, expected)
+			http.Error(response, message, http.StatusUnsupportedMediaType)
+		})
+	}
+}
+
+// BasicAuth challenges requests without valid credentials, except for routes
+// whose "public" meta is true. Routes are only known inside the router, so
+// register it with Router.Use for the exemption to apply.
+func BasicAuth(realm string, verify func(user, password string) bool) func(http.Handler) http.Handler {
+	var challenge = fmt.Sprintf("Basic realm=%q, charset=\"UTF-8\"", realm)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			if route := MatchedRoute(request.Context()); route != nil {
+				if public, _ := route.Meta("public"); public == true {
+					next.ServeHTTP(response, request)
+					return
+				}
+			}
+
+			if user, password, ok := request.BasicAuth(); ok && verify(user, password) {
+				next.ServeHTTP(response, request)
+				return
+			}
+
+			response.Header().Set("WWW-Authenticate", challenge)
+			http.Error(response, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		})
+	}
+}
+
+// Recover turns panics into 500 responses, calling onPanic instead when it is
+// set. It should be the outermost middleware so it covers everything else.
+func Recover(onPanic func(http.ResponseWriter, *http.Request, interface{})) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			defer func() {
+				if err := recover(); err == http.ErrAbortHandler {
+					panic(err)
+				} else if err != nil && onPanic != nil {
+					onPanic(response, request, err)
+				} else if err != nil {
+					http.Error(response, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+
+			next.ServeHTTP(response, request)
+		})
+	}
+}
+
+type LogEntry struct {
+	Method   string
+	Path     string
+	Pattern  string
+	Status   int
+	Bytes    int
+	Duration time.Duration
+}
+
+func Logger(fn func(LogEntry)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			var recorder = &responseRecorder{ResponseWriter: response}
+			var start = time.Now()
+
+			next.ServeHTTP(recorder, request)
+
+			fn(LogEntry{
+				Method:   request.Method,
+				Path:     request.URL.Path,
+				Pattern:  Pattern(request.Context()),
+				Status:   recorder.Status(),
+				Bytes:    recorder.bytes,
+				Duration: time.Since(start),
+			})
+		})
+	}
+}
+
+const timeoutKey = key("timeout")
+
+func Timeout(duration time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			var ctx, cancel = context.WithTimeout(context.WithValue(request.Context(), timeoutKey, duration), duration)
+			defer cancel()
+
+			var writer = &timeoutWriter{response: response, header: make(http.Header)}
+			var done = make(chan struct{})
+			var panicked = make(chan interface{}, 1)
+
+			go func() {
+				defer func() {
+					if err := recover(); err != nil {
+						panicked <- err
+					}
+				}()
+
+				next.ServeHTTP(writer, request.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case err := <-panicked:
+				panic(err)
+			case <-done:
+				writer.finish()
+			case <-ctx.Done():
+				writer.timeout(http.StatusServiceUnavailable)
+			}
+		})
+	}
+}
+
+// fallback applies Timeout only when no Timeout middleware already covers the
+// request, so an explicit one replaces a router's DefaultTimeout.
+func fallback(duration time.Duration, next http.Handler) http.Handler {
+	var timeout = Timeout(duration)(next)
+
+	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if request.Context().Value(timeoutKey) != nil {
+			next.ServeHTTP(response, request)
+		} else {
+			timeout.ServeHTTP(response, request)
+		}
+	})
+}
+
+type Span interface {
+	SetAttribute(key string, value interface{})
+	SetError(description string)
+	End()
+}
+
+type Tracer interface {
+	Start(ctx context.Context, name string, header http.Header) (context.Context, Span)
+}
+
+// Trace starts a span per request, named after the matched route pattern. The
+// pattern is only known inside the router, so register it with Router.Use.
+func Trace(tracer Tracer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			var pattern = Pattern(request.Context())
+			var name = pattern
+
+			if name == "" {
+				name = request.Method
+			}
+
+			var ctx, span = tracer.Start(request.Context(), name, request.Header)
+			var recorder = &responseRecorder{ResponseWriter: response}
+			defer span.End()
+
+			next.ServeHTTP(recorder, request.WithContext(ctx))
+
+			span.SetAttribute("http.method", request.Method)
+			span.SetAttribute("http.route", pattern)
+			span.SetAttribute("http.status_code", recorder.Status())
+
+			if recorder.Status() >= http.StatusInternalServerError {
+				span.SetError(http.StatusText(recorder.Status()))
+			}
+		})
+	}
+}
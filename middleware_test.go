@@ -0,0 +1,453 @@
+package routes
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORS(test *testing.T) {
+	var router = New()
+	router.Use(CORS(CORSOptions{
+		AllowedOrigins:   []string{"https://example.com"},
+		AllowedMethods:   []string{http.MethodGet, http.MethodPut},
+		AllowedHeaders:   []string{"Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	}))
+	router.Add("/users", &Handler{}, "users", http.MethodGet)
+
+	var request = httptest.NewRequest(http.MethodOptions, "/users", nil)
+	request.Header.Set("Origin", "https://example.com")
+	request.Header.Set("Access-Control-Request-Method", http.MethodPut)
+
+	var response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+
+	if response.Code != http.StatusNoContent {
+		test.Fatalf("Expected %d, got %d", http.StatusNoContent, response.Code)
+	}
+
+	var expected = map[string]string{
+		"Access-Control-Allow-Origin":      "https://example.com",
+		"Access-Control-Allow-Methods":     "GET, PUT",
+		"Access-Control-Allow-Headers":     "Content-Type",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Max-Age":           "600",
+	}
+
+	for name, value := range expected {
+		if response.Header().Get(name) != value {
+			test.Fatalf("Expected %s: %s, got %s", name, value, response.Header().Get(name))
+		}
+	}
+
+	request = httptest.NewRequest(http.MethodGet, "/users", nil)
+	request.Header.Set("Origin", "https://evil.com")
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+
+	if response.Code != http.StatusOK || response.Header().Get("Access-Control-Allow-Origin") != "" {
+		test.Fatal("Expected request to pass through without CORS headers!")
+	}
+}
+
+func TestCORSWildcard(test *testing.T) {
+	var handler = CORS(CORSOptions{AllowedOrigins: []string{"*"}})(&Handler{})
+
+	var request = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set("Origin", "https://example.com")
+
+	var response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	if origin := response.Header().Get("Access-Control-Allow-Origin"); origin != "*" {
+		test.Fatalf("Expected *, got %s", origin)
+	}
+}
+
+func TestRecover(test *testing.T) {
+	var recovered interface{}
+	var router = New()
+
+	router.Use(Recover(nil))
+	router.Add("/panic", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}), "panic")
+
+	var response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if response.Code != http.StatusInternalServerError {
+		test.Fatalf("Expected %d, got %d", http.StatusInternalServerError, response.Code)
+	}
+
+	var handler = Recover(func(w http.ResponseWriter, r *http.Request, err interface{}) {
+		recovered = err
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})(router)
+
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if response.Code != http.StatusInternalServerError || recovered != nil {
+		test.Fatal("Expected the inner Recover to handle the panic!")
+	}
+
+	handler = Recover(func(w http.ResponseWriter, r *http.Request, err interface{}) {
+		recovered = err
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if response.Code != http.StatusServiceUnavailable || recovered != "boom" {
+		test.Fatalf("Expected custom handler, got %d %v", response.Code, recovered)
+	}
+}
+
+func TestRecoverAbortHandler(test *testing.T) {
+	var handler = Recover(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if err := recover(); err != http.ErrAbortHandler {
+			test.Fatalf("Expected %v, got %v", http.ErrAbortHandler, err)
+		}
+	}()
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestLogger(test *testing.T) {
+	var entries = make([]LogEntry, 0)
+	var router = New()
+
+	router.Use(Logger(func(entry LogEntry) {
+		entries = append(entries, entry)
+	}))
+	router.Add("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}), "user")
+	router.Add("/created", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}), "created")
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/5", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/created", nil))
+
+	if len(entries) != 2 {
+		test.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+
+	if entry := entries[0]; entry.Method != http.MethodGet || entry.Path != "/users/5" || entry.Pattern != "/users/:id" || entry.Status != http.StatusOK || entry.Bytes != 5 {
+		test.Fatalf("Unexpected entry: %+v", entry)
+	}
+
+	if entry := entries[1]; entry.Status != http.StatusCreated || entry.Bytes != 0 {
+		test.Fatalf("Unexpected entry: %+v", entry)
+	}
+}
+
+func TestLoggerFlush(test *testing.T) {
+	var err error
+	var handler = Logger(func(LogEntry) {})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		_, _, err = w.(http.Hijacker).Hijack()
+	}))
+
+	var response = httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !response.Flushed {
+		test.Fatal("Expected flush to pass through!")
+	} else if err == nil {
+		test.Fatal("Expected hijack error from a writer that cannot hijack!")
+	}
+}
+
+func TestTimeout(test *testing.T) {
+	var cancelled = make(chan struct{})
+	var router = New()
+
+	router.Use(Timeout(10 * time.Millisecond))
+	router.Add("/slow", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(cancelled)
+	}), "slow")
+	router.Add("/fast", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.(http.Flusher).Flush()
+		w.Write([]byte("fast"))
+	}), "fast")
+
+	var response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	if response.Code != http.StatusServiceUnavailable {
+		test.Fatalf("Expected %d, got %d", http.StatusServiceUnavailable, response.Code)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		test.Fatal("Expected the handler context to be cancelled!")
+	}
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/fast", nil))
+
+	if response.Code != http.StatusOK || response.Body.String() != "fast" || !response.Flushed {
+		test.Fatalf("Unexpected response: %d %s", response.Code, response.Body.String())
+	} else if contentType := response.Header().Get("Content-Type"); contentType != "text/plain" {
+		test.Fatalf("Unexpected Content-Type: %s", contentType)
+	}
+}
+
+type recordedSpan struct {
+	name       string
+	parent     string
+	attributes map[string]interface{}
+	err        string
+	ended      bool
+}
+
+func (span *recordedSpan) SetAttribute(key string, value interface{}) {
+	span.attributes[key] = value
+}
+
+func (span *recordedSpan) SetError(description string) {
+	span.err = description
+}
+
+func (span *recordedSpan) End() {
+	span.ended = true
+}
+
+type recordingTracer struct {
+	spans []*recordedSpan
+}
+
+func (tracer *recordingTracer) Start(ctx context.Context, name string, header http.Header) (context.Context, Span) {
+	var s = &recordedSpan{name: name, parent: header.Get("Traceparent"), attributes: make(map[string]interface{})}
+	tracer.spans = append(tracer.spans, s)
+
+	return context.WithValue(ctx, key("span"), s), s
+}
+
+func TestTrace(test *testing.T) {
+	var traced = &recordingTracer{}
+	var router = New()
+	router.Use(Trace(traced))
+
+	router.Add("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(key("span")) == nil {
+			test.Fatal("Expected span in context!")
+		}
+	}), "user")
+	router.Add("/broken", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}), "broken")
+
+	var request = httptest.NewRequest(http.MethodGet, "/users/5", nil)
+	request.Header.Set("Traceparent", "00-trace-parent-01")
+
+	router.ServeHTTP(httptest.NewRecorder(), request)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/broken", nil))
+
+	if len(traced.spans) != 2 {
+		test.Fatalf("Expected 2 spans, got %d", len(traced.spans))
+	}
+
+	if s := traced.spans[0]; s.name != "/users/:id" || s.parent != "00-trace-parent-01" || !s.ended || s.err != "" {
+		test.Fatalf("Unexpected span: %+v", s)
+	} else if s.attributes["http.route"] != "/users/:id" || s.attributes["http.method"] != http.MethodGet || s.attributes["http.status_code"] != http.StatusOK {
+		test.Fatalf("Unexpected attributes: %v", s.attributes)
+	}
+
+	if s := traced.spans[1]; s.name != "/broken" || s.err == "" {
+		test.Fatalf("Unexpected span: %+v", s)
+	}
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+
+	hijacked bool
+}
+
+func (recorder *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	recorder.hijacked = true
+	var conn, _ = net.Pipe()
+
+	return conn, bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn)), nil
+}
+
+func TestHijack(test *testing.T) {
+	var underlying http.ResponseWriter
+	var err error
+
+	var router = New(WithHandleHEAD(), WithOnRequest(func(RequestStats) {}))
+	router.Use(Logger(func(LogEntry) {}), Recover(nil), Trace(&recordingTracer{}), Compress(5), Timeout(time.Second))
+	router.GET("/socket", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		underlying = Unwrap(w)
+
+		var conn net.Conn
+
+		if conn, _, err = w.(http.Hijacker).Hijack(); err == nil {
+			conn.Close()
+		}
+	}))
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		var response = &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+		router.ServeHTTP(response, httptest.NewRequest(method, "/socket", nil))
+
+		if err != nil || !response.hijacked {
+			test.Fatalf("Expected %s hijack to pass through, got %v", method, err)
+		} else if underlying != response {
+			test.Fatalf("Expected Unwrap to reach the underlying writer, got %T", underlying)
+		}
+	}
+}
+
+func TestRequireContentType(test *testing.T) {
+	var handler = RequireContentType("application/json")(&Handler{})
+
+	var tests = []struct {
+		method      string
+		contentType string
+		expected    int
+	}{
+		{http.MethodPost, "application/json", http.StatusOK},
+		{http.MethodPost, "application/json; charset=utf-8", http.StatusOK},
+		{http.MethodPut, "Application/JSON;charset=UTF-8", http.StatusOK},
+		{http.MethodPatch, "text/plain; charset=utf-8", http.StatusUnsupportedMediaType},
+		{http.MethodPost, "application/jsonp", http.StatusUnsupportedMediaType},
+		{http.MethodPost, "", http.StatusUnsupportedMediaType},
+		{http.MethodGet, "", http.StatusOK},
+		{http.MethodDelete, "text/plain", http.StatusOK},
+	}
+
+	for _, t := range tests {
+		var response = httptest.NewRecorder()
+		var request = httptest.NewRequest(t.method, "/", nil)
+		request.Header.Set("Content-Type", t.contentType)
+		handler.ServeHTTP(response, request)
+
+		if response.Code != t.expected {
+			test.Fatalf("Expected %d for %s %q, got %d", t.expected, t.method, t.contentType, response.Code)
+		}
+	}
+
+	var response = httptest.NewRecorder()
+	RequireContentTypeFor([]string{http.MethodGet}, "application/json")(&Handler{}).ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if response.Code != http.StatusUnsupportedMediaType {
+		test.Fatalf("Expected %d, got %d", http.StatusUnsupportedMediaType, response.Code)
+	}
+}
+
+func TestBasicAuth(test *testing.T) {
+	var router = New()
+	router.Use(BasicAuth("admin", func(user, password string) bool {
+		return user == "admin" && password == "secret"
+	}))
+	router.Add("/health", &Handler{}, "health").SetMeta("public", true)
+	router.Add("/dashboard", &Handler{}, "dashboard")
+
+	var tests = []struct {
+		path     string
+		user     string
+		password string
+		expected int
+	}{
+		{"/health", "", "", http.StatusOK},
+		{"/dashboard", "", "", http.StatusUnauthorized},
+		{"/dashboard", "admin", "wrong", http.StatusUnauthorized},
+		{"/dashboard", "admin", "secret", http.StatusOK},
+	}
+
+	for _, t := range tests {
+		var response = httptest.NewRecorder()
+		var request = httptest.NewRequest(http.MethodGet, t.path, nil)
+
+		if t.user != "" {
+			request.SetBasicAuth(t.user, t.password)
+		}
+
+		router.ServeHTTP(response, request)
+
+		if response.Code != t.expected {
+			test.Fatalf("Expected %d for %s as %q, got %d", t.expected, t.path, t.user, response.Code)
+		} else if challenge := response.Header().Get("WWW-Authenticate"); t.expected == http.StatusUnauthorized && challenge != `Basic realm="admin", charset="UTF-8"` {
+			test.Fatalf("Unexpected challenge: %q", challenge)
+		}
+	}
+}
+
+func TestDefaultTimeout(test *testing.T) {
+	var slow = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(50 * time.Millisecond):
+			w.WriteHeader(http.StatusAccepted)
+		}
+	})
+
+	var router = New(WithDefaultTimeout(10*time.Millisecond), WithNotFoundHandler(slow))
+	router.Add("/slow", slow, "slow")
+	router.Add("/patient", slow, "patient").Timeout(time.Second)
+	router.Add("/middleware", slow, "middleware").Use(Timeout(time.Second))
+	router.Add("/shorter", slow, "shorter").Use(Timeout(5 * time.Millisecond))
+
+	var api = New(WithDefaultTimeout(time.Millisecond))
+	api.Add("/users", slow, "users")
+	router.AddRouter("/api", api, "api")
+
+	var tests = map[string]int{
+		"/slow":       http.StatusServiceUnavailable,
+		"/missing":    http.StatusServiceUnavailable,
+		"/patient":    http.StatusAccepted,
+		"/middleware": http.StatusAccepted,
+		"/shorter":    http.StatusServiceUnavailable,
+		"/api/users":  http.StatusServiceUnavailable,
+	}
+
+	for path, expected := range tests {
+		var response = httptest.NewRecorder()
+		router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if response.Code != expected {
+			test.Fatalf("Expected %d for %s, got %d", expected, path, response.Code)
+		}
+	}
+}
+
+func TestDefaultTimeoutFailedMount(test *testing.T) {
+	var api = New(WithDefaultTimeout(time.Millisecond))
+	api.Add("/users", &Handler{}, "users")
+
+	var router = New()
+	router.AddRouter("/api", api, "api")
+	router.Add("/*rest", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusAccepted)
+	}), "rest")
+
+	var response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/api/missing", nil))
+
+	if response.Code != http.StatusAccepted {
+		test.Fatalf("Expected %d, got %d", http.StatusAccepted, response.Code)
+	}
+}
@@ -0,0 +1,64 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const prefixKey = key("prefix")
+
+type mounted struct {
+	handler http.Handler
+}
+
+func (router *Router) Mount(prefix string, handler http.Handler) {
+	var mount = &mounted{handler: handler}
+	var pattern = strings.TrimRight(prefix, "/")
+
+	router.Add(pattern, mount, "")
+	router.Add(pattern+"/*path", mount, "")
+}
+
+func (handler *mounted) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	var rest = "/"
+
+	if m, ok := request.Context().Value(matchKey).(*match); ok {
+		rest = m.remaining()
+	}
+
+	var location = new(url.URL)
+	*location = *request.URL
+	location.Path = rest
+	location.RawPath = ""
+
+	var prefix = strings.TrimSuffix(request.URL.Path, strings.TrimPrefix(rest, "/"))
+	var inner = request.WithContext(context.WithValue(request.Context(), prefixKey, stripped(request)+strings.TrimRight(prefix, "/")))
+	inner.URL = location
+
+	handler.handler.ServeHTTP(response, inner)
+}
+
+// stripped returns the path prefix that Mount or Adapt removed before the
+// request reached the router, so redirects can point back at the full path.
+func stripped(request *http.Request) string {
+	var prefix, _ = request.Context().Value(prefixKey).(string)
+	return prefix
+}
+
+func Adapt(mux *http.ServeMux, router *Router, pattern string) {
+	var prefix = pattern
+
+	if index := strings.IndexRune(prefix, '/'); index != -1 {
+		prefix = prefix[index:]
+	}
+
+	prefix = strings.TrimRight(prefix, "/")
+
+	var handler = http.StripPrefix(prefix, router)
+
+	mux.Handle(pattern, http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		handler.ServeHTTP(response, request.WithContext(context.WithValue(request.Context(), prefixKey, stripped(request)+prefix)))
+	}))
+}
@@ -0,0 +1,115 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMount(test *testing.T) {
+	var paths []string
+	var mux = http.NewServeMux()
+
+	mux.HandleFunc("/pprof/", func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, "index:"+r.URL.Path)
+	})
+
+	var router = New()
+	router.Add("/debug/vars", &Handler{}, "vars")
+	router.Mount("/debug", mux)
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug", "/debug/vars"} {
+		var request = httptest.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), request)
+
+		if request.URL.Path != path {
+			test.Fatalf("Expected %s, got %s", path, request.URL.Path)
+		}
+	}
+
+	var expected = []string{"/pprof/", "/pprof/heap", "index:/"}
+
+	if len(paths) != len(expected) {
+		test.Fatalf("Expected %v, got %v", expected, paths)
+	}
+
+	for index := range expected {
+		if paths[index] != expected[index] {
+			test.Fatalf("Expected %v, got %v", expected, paths)
+		}
+	}
+}
+
+func TestAdapt(test *testing.T) {
+	var parameters []string
+	var router = New()
+
+	router.Add("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parameters = append(parameters, Param(r.Context(), "id"))
+	}), "user")
+
+	var mux = http.NewServeMux()
+	Adapt(mux, router, "/api/")
+
+	for path, status := range map[string]int{
+		"/api/users/1": http.StatusOK,
+		"/api/posts/1": http.StatusNotFound,
+		"/users/1":     http.StatusNotFound,
+	} {
+		var response = httptest.NewRecorder()
+		mux.ServeHTTP(response, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if response.Code != status {
+			test.Fatalf("Expected %d for %s, got %d", status, path, response.Code)
+		}
+	}
+
+	if len(parameters) != 1 || parameters[0] != "1" {
+		test.Fatalf("Unexpected parameters: %v", parameters)
+	}
+}
+
+func TestAdaptRedirects(test *testing.T) {
+	var router = New(WithRedirectTrailingSlash())
+	router.Add("/users", &Handler{}, "users")
+	router.Add("/login", &Handler{}, "login").RequireScheme("https")
+
+	var mux = http.NewServeMux()
+	Adapt(mux, router, "/api/")
+
+	for path, location := range map[string]string{
+		"/api/users/":        "/api/users",
+		"/api/users/?page=2": "/api/users?page=2",
+		"/api/login?next=/":  "https://example.com/api/login?next=/",
+	} {
+		var response = httptest.NewRecorder()
+		mux.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "http://example.com"+path, nil))
+
+		if response.Code != http.StatusMovedPermanently || response.Header().Get("Location") != location {
+			test.Fatalf("Expected redirect to %s for %s, got %d %s", location, path, response.Code, response.Header().Get("Location"))
+		}
+	}
+}
+
+func TestMountRedirects(test *testing.T) {
+	var inner = New(WithCleanPath(), WithRedirectTrailingSlash())
+	inner.Add("/users/:id", &Handler{}, "user")
+
+	var router = New()
+	router.Mount("/api", inner)
+
+	for path, location := range map[string]string{
+		"/api/users//1": "/api/users/1",
+		"/api/users/1/": "/api/users/1",
+	} {
+		var response = httptest.NewRecorder()
+		router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if response.Code != http.StatusMovedPermanently || response.Header().Get("Location") != location {
+			test.Fatalf("Expected redirect to %s for %s, got %d %s", location, path, response.Code, response.Header().Get("Location"))
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const mediaKey = key("media")
+
+type accept struct {
+	media   string
+	quality float64
+}
+
+type negotiator struct {
+	handlers map[string]http.Handler
+	types    []string
+	fallback string
+}
+
+func Negotiate(handlers map[string]http.Handler, fallback string) http.Handler {
+	var types = make([]string, 0, len(handlers))
+
+	for media := range handlers {
+		types = append(types, media)
+	}
+
+	sort.Strings(types)
+
+	return &negotiator{handlers: handlers, types: types, fallback: fallback}
+}
+
+func MediaType(ctx context.Context) string {
+	if media, ok := ctx.Value(mediaKey).(string); ok {
+		return media
+	}
+
+	return ""
+}
+
+func parseAccept(header string) []accept {
+	var accepts = make([]accept, 0)
+
+	for _, part := range strings.Split(header, ",") {
+		var fields = strings.Split(part, ";")
+		var entry = accept{media: strings.ToLower(strings.TrimSpace(fields[0])), quality: 1}
+
+		for _, field := range fields[1:] {
+			if field = strings.TrimSpace(field); strings.HasPrefix(field, "q=") {
+				if quality, err := strconv.ParseFloat(field[2:], 64); err == nil {
+					entry.quality = quality
+				}
+			}
+		}
+
+		if entry.media != "" {
+			accepts = append(accepts, entry)
+		}
+	}
+
+	sort.SliceStable(accepts, func(i, j int) bool {
+		return accepts[i].quality > accepts[j].quality
+	})
+
+	return accepts
+}
+
+func (entry accept) matches(media string) bool {
+	if entry.media == "*/*" || entry.media == media {
+		return true
+	}
+
+	return strings.HasSuffix(entry.media, "/*") && strings.HasPrefix(media, entry.media[:len(entry.media)-1])
+}
+
+func (entry accept) specificity() int {
+	if entry.media == "*/*" {
+		return 0
+	} else if strings.HasSuffix(entry.media, "/*") {
+		return 1
+	}
+
+	return 2
+}
+
+// excluded reports whether the most specific range matching media has q=0,
+// so "application/json;q=0, */*" rules out JSON despite the wildcard.
+func excluded(accepts []accept, media string) bool {
+	var quality, specificity = 1.0, -1
+
+	for _, entry := range accepts {
+		if entry.matches(media) && entry.specificity() > specificity {
+			quality, specificity = entry.quality, entry.specificity()
+		}
+	}
+
+	return specificity >= 0 && quality == 0
+}
+
+func (handler *negotiator) choose(header string) string {
+	if header == "" {
+		return handler.fallback
+	}
+
+	var accepts = parseAccept(header)
+
+	for _, entry := range accepts {
+		if entry.quality == 0 {
+			continue
+		}
+
+		if _, ok := handler.handlers[handler.fallback]; ok && entry.matches(handler.fallback) && !excluded(accepts, handler.fallback) {
+			return handler.fallback
+		}
+
+		for _, media := range handler.types {
+			if entry.matches(media) && !excluded(accepts, media) {
+				return media
+			}
+		}
+	}
+
+	if excluded(accepts, handler.fallback) {
+		return ""
+	}
+
+	return handler.fallback
+}
+
+func (handler *negotiator) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	var media = handler.choose(request.Header.Get("Accept"))
+	response.Header().Add("Vary", "Accept")
+
+	if next, ok := handler.handlers[media]; ok {
+		next.ServeHTTP(response, request.WithContext(context.WithValue(request.Context(), mediaKey, media)))
+	} else {
+		response.WriteHeader(http.StatusNotAcceptable)
+	}
+}
@@ -0,0 +1,70 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiate(test *testing.T) {
+	var served string
+	var handler = func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served = name + ":" + MediaType(r.Context())
+		})
+	}
+
+	var router = New()
+	router.Add("/report", Negotiate(map[string]http.Handler{
+		"application/json": handler("json"),
+		"application/xml":  handler("xml"),
+	}, "application/json"), "report")
+
+	var tests = map[string]string{
+		"":                "json:application/json",
+		"application/xml": "xml:application/xml",
+		"application/json;q=0.5, application/xml":       "xml:application/xml",
+		"application/xml;q=0.1, application/json;q=0.9": "json:application/json",
+		"application/*":                       "json:application/json",
+		"text/html, */*;q=0.1":                "json:application/json",
+		"text/html":                           "json:application/json",
+		"application/xml;q=0, application/*":  "json:application/json",
+		"application/json;q=0, */*":           "xml:application/xml",
+		"application/json;q=0, application/*": "xml:application/xml",
+	}
+
+	for accept, expected := range tests {
+		var request = httptest.NewRequest(http.MethodGet, "/report", nil)
+		request.Header.Set("Accept", accept)
+		served = ""
+
+		var response = httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		if served != expected {
+			test.Errorf("Test '%s' failed!", accept)
+			test.Errorf("Expected: %s", expected)
+			test.Fatalf("Got: %s", served)
+		} else if vary := response.Header().Get("Vary"); vary != "Accept" {
+			test.Fatalf("Expected Vary: Accept, got '%s'", vary)
+		}
+	}
+}
+
+func TestNegotiateNotAcceptable(test *testing.T) {
+	var handler = Negotiate(map[string]http.Handler{"application/xml": &Handler{}}, "application/json")
+
+	for _, accept := range []string{"text/html", "application/json;q=0, text/html"} {
+		var request = httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set("Accept", accept)
+
+		var response = httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+
+		if response.Code != http.StatusNotAcceptable {
+			test.Fatalf("Expected %d, got %d", http.StatusNotAcceptable, response.Code)
+		} else if vary := response.Header().Get("Vary"); vary != "Accept" {
+			test.Fatalf("Expected Vary: Accept, got '%s'", vary)
+		}
+	}
+}
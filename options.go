@@ -0,0 +1,94 @@
+package routes
+
+import (
+	"net/http"
+	"time"
+)
+
+type Option func(router *Router)
+
+func WithNotFoundHandler(handler http.Handler) Option {
+	return func(router *Router) {
+		router.NotFoundHandler = handler
+	}
+}
+
+func WithMethodNotAllowedHandler(handler http.Handler) Option {
+	return func(router *Router) {
+		router.MethodNotAllowedHandler = handler
+	}
+}
+
+func WithRedirectTrailingSlash() Option {
+	return func(router *Router) {
+		router.RedirectTrailingSlash = true
+	}
+}
+
+func WithCaseInsensitive() Option {
+	return func(router *Router) {
+		router.CaseInsensitive = true
+	}
+}
+
+func WithHandleOPTIONS() Option {
+	return func(router *Router) {
+		router.HandleOPTIONS = true
+	}
+}
+
+func WithMiddleware(middleware ...func(http.Handler) http.Handler) Option {
+	return func(router *Router) {
+		router.middleware = append(router.middleware, middleware...)
+	}
+}
+
+func WithHandleHEAD() Option {
+	return func(router *Router) {
+		router.HandleHEAD = true
+	}
+}
+
+func WithErrorHandler(handler func(http.ResponseWriter, *http.Request, error)) Option {
+	return func(router *Router) {
+		router.ErrorHandler = handler
+	}
+}
+
+func WithCleanPath() Option {
+	return func(router *Router) {
+		router.CleanPath = true
+	}
+}
+
+func WithLastResortNotFound() Option {
+	return func(router *Router) {
+		router.LastResortNotFound = true
+	}
+}
+
+func WithDefaultTimeout(timeout time.Duration) Option {
+	return func(router *Router) {
+		router.DefaultTimeout = timeout
+	}
+}
+
+func WithOnRequest(fn func(RequestStats)) Option {
+	return func(router *Router) {
+		router.OnRequest = fn
+	}
+}
+
+func WithCache(size int) Option {
+	return func(router *Router) {
+		if size > 0 {
+			router.cache = newCache(size)
+		}
+	}
+}
+
+func WithTrailingSlash(policy SlashPolicy) Option {
+	return func(router *Router) {
+		router.TrailingSlash = policy
+	}
+}
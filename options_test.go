@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOptions(test *testing.T) {
+	var notFound = &Handler{}
+	var router = New(
+		WithNotFoundHandler(notFound),
+		WithRedirectTrailingSlash(),
+		WithCaseInsensitive(),
+		WithHandleOPTIONS(),
+		WithHandleHEAD(),
+		WithCleanPath(),
+	)
+
+	if router.NotFoundHandler != notFound || !router.RedirectTrailingSlash || !router.CaseInsensitive || !router.HandleOPTIONS || !router.HandleHEAD || !router.CleanPath {
+		test.Fatal("Expected options to be applied!")
+	}
+
+	var called = false
+
+	router = New(WithMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			next.ServeHTTP(w, r)
+		})
+	}))
+	router.Add("/users", &Handler{}, "users")
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	if !called {
+		test.Fatal("Expected middleware to be called!")
+	}
+}
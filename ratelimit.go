@@ -0,0 +1,108 @@
+package routes
+
+import (
+	"container/list"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const buckets = 10000
+
+type bucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+type limiter struct {
+	rate    float64
+	burst   float64
+	size    int
+	order   *list.List
+	buckets map[string]*list.Element
+	mutex   sync.Mutex
+}
+
+func newLimiter(rps float64, burst, size int) *limiter {
+	return &limiter{rate: rps, burst: float64(burst), size: size, order: list.New(), buckets: make(map[string]*list.Element)}
+}
+
+func RemoteIP(request *http.Request) string {
+	if host, _, err := net.SplitHostPort(request.RemoteAddr); err == nil {
+		return host
+	}
+
+	return request.RemoteAddr
+}
+
+func ForwardedIP(request *http.Request) string {
+	if forwarded := request.Header.Get("X-Forwarded-For"); forwarded != "" {
+		if index := strings.IndexRune(forwarded, ','); index != -1 {
+			forwarded = forwarded[:index]
+		}
+
+		return strings.TrimSpace(forwarded)
+	}
+
+	return RemoteIP(request)
+}
+
+func (limiter *limiter) take(key string, now time.Time) (bool, time.Duration) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	var entry *bucket
+
+	if element, ok := limiter.buckets[key]; ok {
+		entry = element.Value.(*bucket)
+		limiter.order.MoveToFront(element)
+	} else {
+		entry = &bucket{key: key, tokens: limiter.burst, last: now}
+		limiter.buckets[key] = limiter.order.PushFront(entry)
+
+		if limiter.order.Len() > limiter.size {
+			var oldest = limiter.order.Back()
+
+			limiter.order.Remove(oldest)
+			delete(limiter.buckets, oldest.Value.(*bucket).key)
+		}
+	}
+
+	entry.tokens = math.Min(limiter.burst, entry.tokens+now.Sub(entry.last).Seconds()*limiter.rate)
+	entry.last = now
+
+	if entry.tokens >= 1 {
+		entry.tokens--
+		return true, 0
+	}
+
+	return false, time.Duration((1 - entry.tokens) / limiter.rate * float64(time.Second))
+}
+
+func RateLimit(rps float64, burst int, key func(*http.Request) string) func(http.Handler) http.Handler {
+	if rps <= 0 || burst < 1 {
+		panic("Rate and burst must be positive!")
+	}
+
+	var limiter = newLimiter(rps, burst, buckets)
+
+	if key == nil {
+		key = RemoteIP
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			if ok, wait := limiter.take(key(request), time.Now()); ok {
+				next.ServeHTTP(response, request)
+			} else {
+				response.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(response, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			}
+		})
+	}
+}
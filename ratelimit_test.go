@@ -0,0 +1,96 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(test *testing.T) {
+	var router = New()
+	router.Add("/login", &Handler{}, "login").Use(RateLimit(0.5, 2, nil))
+	router.Add("/home", &Handler{}, "home")
+
+	var serve = func(path, address string) *httptest.ResponseRecorder {
+		var response = httptest.NewRecorder()
+		var request = httptest.NewRequest(http.MethodGet, path, nil)
+		request.RemoteAddr = address
+		router.ServeHTTP(response, request)
+
+		return response
+	}
+
+	for index, expected := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if response := serve("/login", "10.0.0.1:1234"); response.Code != expected {
+			test.Fatalf("Expected %d for request %d, got %d", expected, index, response.Code)
+		} else if expected == http.StatusTooManyRequests && response.Header().Get("Retry-After") != "2" {
+			test.Fatalf("Unexpected Retry-After: %q", response.Header().Get("Retry-After"))
+		}
+	}
+
+	if response := serve("/login", "10.0.0.2:1234"); response.Code != http.StatusOK {
+		test.Fatalf("Expected a separate bucket per client, got %d", response.Code)
+	} else if response := serve("/home", "10.0.0.1:1234"); response.Code != http.StatusOK {
+		test.Fatalf("Expected other routes to be unlimited, got %d", response.Code)
+	}
+}
+
+func TestRateLimitRefill(test *testing.T) {
+	var limiter = newLimiter(2, 1, buckets)
+	var now = time.Now()
+
+	if ok, _ := limiter.take("a", now); !ok {
+		test.Fatal("Expected first token!")
+	} else if ok, wait := limiter.take("a", now); ok || wait != 500*time.Millisecond {
+		test.Fatalf("Expected to wait 500ms, got %v", wait)
+	} else if ok, _ := limiter.take("a", now.Add(500*time.Millisecond)); !ok {
+		test.Fatal("Expected refilled token!")
+	}
+}
+
+func TestRateLimitEviction(test *testing.T) {
+	var limiter = newLimiter(1, 1, 2)
+	var now = time.Now()
+
+	for _, key := range []string{"a", "b", "a", "c"} {
+		limiter.take(key, now)
+	}
+
+	if len(limiter.buckets) != 2 || limiter.order.Len() != 2 {
+		test.Fatalf("Expected 2 buckets, got %d", len(limiter.buckets))
+	} else if _, ok := limiter.buckets["b"]; ok {
+		test.Fatal("Expected the least recently used bucket to be evicted!")
+	} else if ok, _ := limiter.take("a", now); ok {
+		test.Fatal("Expected the recently used bucket to be kept!")
+	}
+}
+
+func TestRateLimitInvalid(test *testing.T) {
+	for _, rate := range []float64{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					test.Fatalf("Expected a panic for rate %v", rate)
+				}
+			}()
+
+			RateLimit(rate, 1, nil)
+		}()
+	}
+}
+
+func TestForwardedIP(test *testing.T) {
+	var request = httptest.NewRequest(http.MethodGet, "/", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+
+	if ip := ForwardedIP(request); ip != "10.0.0.1" {
+		test.Fatalf("Expected 10.0.0.1, got %s", ip)
+	}
+
+	request.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+
+	if ip := ForwardedIP(request); ip != "203.0.113.7" {
+		test.Fatalf("Expected 203.0.113.7, got %s", ip)
+	}
+}
@@ -0,0 +1,198 @@
+package routes
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// Unwrap returns the writer underneath every wrapper this package inserts.
+// The Logger, Trace, Compress, ETag and Timeout wrappers, the HEAD writer and
+// the OnRequest recorder all forward Flush and Hijack to it; Recover and the
+// remaining middleware pass the writer through untouched.
+func Unwrap(response http.ResponseWriter) http.ResponseWriter {
+	for {
+		if wrapper, ok := response.(interface{ Unwrap() http.ResponseWriter }); ok {
+			response = wrapper.Unwrap()
+		} else {
+			return response
+		}
+	}
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+
+	status int
+	bytes  int
+}
+
+func (recorder *responseRecorder) WriteHeader(status int) {
+	if recorder.status == 0 {
+		recorder.status = status
+	}
+
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *responseRecorder) Write(data []byte) (int, error) {
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+
+	var n, err = recorder.ResponseWriter.Write(data)
+	recorder.bytes += n
+
+	return n, err
+}
+
+func (recorder *responseRecorder) Status() int {
+	if recorder.status == 0 {
+		return http.StatusOK
+	}
+
+	return recorder.status
+}
+
+func (recorder *responseRecorder) Flush() {
+	if flusher, ok := recorder.ResponseWriter.(http.Flusher); ok {
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		flusher.Flush()
+	}
+}
+
+func (recorder *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := recorder.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+
+	return nil, nil, errors.New("Hijacking not supported!")
+}
+
+func (recorder *responseRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
+
+type timeoutWriter struct {
+	response http.ResponseWriter
+	header   http.Header
+	mutex    sync.Mutex
+	written  bool
+	timedOut bool
+}
+
+func (writer *timeoutWriter) Header() http.Header {
+	return writer.header
+}
+
+func (writer *timeoutWriter) write() {
+	if !writer.written {
+		var header = writer.response.Header()
+
+		for key, values := range writer.header {
+			header[key] = values
+		}
+
+		writer.written = true
+	}
+}
+
+func (writer *timeoutWriter) WriteHeader(status int) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	if !writer.timedOut {
+		writer.write()
+		writer.response.WriteHeader(status)
+	}
+}
+
+func (writer *timeoutWriter) Write(data []byte) (int, error) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	if writer.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	writer.write()
+
+	return writer.response.Write(data)
+}
+
+func (writer *timeoutWriter) Flush() {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	if flusher, ok := writer.response.(http.Flusher); ok && !writer.timedOut {
+		writer.write()
+		flusher.Flush()
+	}
+}
+
+func (writer *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	if hijacker, ok := writer.response.(http.Hijacker); !ok {
+		return nil, nil, errors.New("Hijacking not supported!")
+	} else if writer.timedOut {
+		return nil, nil, http.ErrHandlerTimeout
+	} else {
+		writer.written = true
+		return hijacker.Hijack()
+	}
+}
+
+func (writer *timeoutWriter) Unwrap() http.ResponseWriter {
+	return writer.response
+}
+
+func (writer *timeoutWriter) finish() {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	writer.write()
+}
+
+func (writer *timeoutWriter) timeout(status int) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	if !writer.written {
+		writer.response.WriteHeader(status)
+	}
+
+	writer.timedOut = true
+}
+
+type headWriter struct {
+	http.ResponseWriter
+}
+
+func (writer *headWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (writer *headWriter) Flush() {
+	if flusher, ok := writer.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (writer *headWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := writer.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+
+	return nil, nil, errors.New("Hijacking not supported!")
+}
+
+func (writer *headWriter) Unwrap() http.ResponseWriter {
+	return writer.ResponseWriter
+}
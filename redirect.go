@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type redirect struct {
+	target string
+	status int
+}
+
+func Redirect(target string, status int) http.Handler {
+	return &redirect{target: target, status: status}
+}
+
+func (handler *redirect) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	var parts = strings.Split(handler.target, "/")
+
+	for index, part := range parts {
+		if part == "" {
+			continue
+		} else if part[0] == ParameterRune {
+			parts[index] = url.PathEscape(Param(request.Context(), parameter(part)))
+		} else if part[0] == GreedyParameterRune {
+			var values = strings.Split(Param(request.Context(), part[1:]), "/")
+
+			for i := range values {
+				values[i] = url.PathEscape(values[i])
+			}
+
+			parts[index] = strings.Join(values, "/")
+		}
+	}
+
+	http.Redirect(response, request, strings.Join(parts, "/"), handler.status)
+}
@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirect(test *testing.T) {
+	var router = New()
+	router.Add("/old/:id", Redirect("/new/:id", http.StatusPermanentRedirect), "old")
+	router.Add("/files/*path", Redirect("/static/*path", http.StatusFound), "files")
+
+	var tests = map[string]struct {
+		status   int
+		location string
+	}{
+		"/old/42":        {http.StatusPermanentRedirect, "/new/42"},
+		"/files/css/app": {http.StatusFound, "/static/css/app"},
+	}
+
+	for path, expected := range tests {
+		var response = httptest.NewRecorder()
+		router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if response.Code != expected.status || response.Header().Get("Location") != expected.location {
+			test.Errorf("Test '%s' failed!", path)
+			test.Fatalf("Got: %d %s", response.Code, response.Header().Get("Location"))
+		}
+	}
+
+	if path, err := router.Reverse("old", "42"); err != nil {
+		test.Fatal(err)
+	} else if path != "/old/42" {
+		test.Fatal(path)
+	}
+}
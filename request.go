@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+const requestKey = key("request")
+
+func uuid() string {
+	var data = make([]byte, 16)
+	rand.Read(data)
+
+	data[6] = data[6]&0x0f | 0x40
+	data[8] = data[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", data[0:4], data[4:6], data[6:8], data[8:10], data[10:])
+}
+
+func RequestID() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			var id = request.Header.Get("X-Request-Id")
+
+			if id == "" || len(id) > 128 {
+				id = uuid()
+			}
+
+			response.Header().Set("X-Request-Id", id)
+			next.ServeHTTP(response, request.WithContext(context.WithValue(request.Context(), requestKey, id)))
+		})
+	}
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(requestKey).(string); ok {
+		return id
+	}
+
+	return ""
+}
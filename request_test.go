@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestID(test *testing.T) {
+	var id, parameter string
+	var router = New()
+
+	router.Use(RequestID())
+	router.Add("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, parameter = RequestIDFromContext(r.Context()), Param(r.Context(), "id")
+	}), "user")
+
+	var response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	if !types["uuid"].MatchString(id) {
+		test.Fatalf("Expected generated UUID, got %s", id)
+	} else if header := response.Header().Get("X-Request-Id"); header != id {
+		test.Fatalf("Expected %s, got %s", id, header)
+	} else if parameter != "1" {
+		test.Fatalf("Expected path parameter to be unaffected, got %s", parameter)
+	}
+
+	var request = httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	request.Header.Set("X-Request-Id", "abc-123")
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+
+	if id != "abc-123" || response.Header().Get("X-Request-Id") != "abc-123" {
+		test.Fatalf("Expected incoming ID, got %s", id)
+	}
+}
@@ -3,209 +3,1945 @@ package routes
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 )
 
 const (
 	ParameterRune       = ':'
 	GreedyParameterRune = '*'
+	OptionalRune        = '?'
 	Key                 = key("parameters")
+	matchKey            = key("match")
 )
 
+var (
+	ErrNotFound          = errors.New("Not found!")
+	ErrMethodNotAllowed  = errors.New("Method not allowed!")
+	ErrNameNotFound      = errors.New("Name not found!")
+	ErrNamespaceNotFound = errors.New("Namespace not found!")
+)
+
+var types = map[string]*regexp.Regexp{
+	"int":  regexp.MustCompile(`^-?[0-9]+$`),
+	"uuid": regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`),
+	"slug": regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`),
+}
+
 type key string
 
-type Router struct {
-	NotFoundHandler http.Handler
-
-	nodes         map[string]*Router
-	names         map[string]string
-	parameterized bool
-	greedy        bool
-	handler       http.Handler
-	parameters    []string
-	name          string
-	pattern       string
-	route         bool
-}
-
-func (router *Router) update(path []string, node *Router, name string) {
-	var parameterized bool
-	var greedy bool
-
-	if strings.HasPrefix(path[0], string(ParameterRune)) {
-		parameterized = true
-		router.parameters = append(router.parameters, strings.TrimPrefix(path[0], ":"))
-		path[0] = ""
-	} else if strings.HasPrefix(path[0], string(GreedyParameterRune)) {
-		router.greedy = true
-		router.parameters = append(router.parameters, strings.TrimPrefix(path[0], "*"))
-		router.handler = node.handler
+type MissingParameterError struct {
+	Parameter string
+}
+
+func (err *MissingParameterError) Error() string {
+	return fmt.Sprintf("Missing parameter '%s'!", err.Parameter)
+}
+
+type Route struct {
+	Methods []string
+
+	handler    http.Handler
+	handlers   map[string]http.Handler
+	name       string
+	pattern    string
+	parts      []string
+	parameters []string
+	middleware []func(http.Handler) http.Handler
+	slash      bool
+	greedy     bool
+	depth      int
+	optional   int
+	meta       map[string]interface{}
+	query      map[string]string
+	defaults   map[string]string
+	timeout    time.Duration
+	scheme     string
+	matchers   []Matcher
+	router     *Router
+}
+
+func (route *Route) Name() string {
+	return route.name
+}
+
+func (route *Route) Pattern() string {
+	return route.pattern
+}
+
+func (route *Route) Named(name string) *Route {
+	if router := route.router; router != nil {
+		router.mutex.Lock()
+		defer router.mutex.Unlock()
+
+		if router.names[route.name] == route {
+			delete(router.names, route.name)
+		}
+
+		if previous, ok := router.names[name]; ok && previous != route {
+			for _, leaf := range router.leaves(previous) {
+				leaf.remove(previous)
+			}
+
+			previous.router = nil
+		}
+
+		if name != "" {
+			router.names[name] = route
+		}
+	}
+
+	route.name = name
+
+	return route
+}
+
+func (route *Route) Parameter(path, name string) (string, bool) {
+	var value, found = "", false
+	var rest = strings.Trim(path, "/")
+
+	for _, part := range route.parts {
+		var optional = strip(part) != part
+		var segment = rest
+
+		if part = strip(part); rest == "" && optional {
+			break
+		} else if part[0] == GreedyParameterRune {
+			rest = ""
+		} else if index := strings.IndexRune(rest, '/'); index != -1 {
+			segment, rest = rest[:index], rest[index+1:]
+		} else {
+			rest = ""
+		}
+
+		if segment == "" {
+			return "", false
+		} else if part[0] != ParameterRune && part[0] != GreedyParameterRune {
+			if segment != part {
+				return "", false
+			}
+		} else if key, _ := constraint(part); key != "" && !types[key[1:]].MatchString(segment) {
+			return "", false
+		} else if parameter(part) == name && !found {
+			value, found = segment, true
+		}
+	}
+
+	if rest != "" {
+		return "", false
+	}
+
+	return value, found
+}
+
+func (route *Route) Meta(key string) (interface{}, bool) {
+	var value, ok = route.meta[key]
+	return value, ok
+}
+
+func (route *Route) SetMeta(key string, value interface{}) *Route {
+	var meta = make(map[string]interface{}, len(route.meta)+1)
+
+	for k, v := range route.meta {
+		meta[k] = v
+	}
+
+	meta[key] = value
+	route.meta = meta
+
+	return route
+}
+
+func (route *Route) Method(method string, handler http.Handler) *Route {
+	var handlers = make(map[string]http.Handler, len(route.handlers)+1)
+
+	for k, v := range route.handlers {
+		handlers[k] = v
+	}
+
+	method = strings.ToUpper(method)
+	handlers[method] = handler
+	route.handlers = handlers
+
+	if len(route.Methods) == 0 && route.handler != nil {
+		return route
+	} else if len(route.Methods) == 0 || !route.allows(method) {
+		route.Methods = append(route.Methods[:len(route.Methods):len(route.Methods)], method)
+	}
+
+	return route
+}
+
+func (route *Route) endpoint(method string) http.Handler {
+	if len(route.handlers) > 0 {
+		if handler, ok := route.handlers[strings.ToUpper(method)]; ok {
+			return handler
+		} else if route.handler == nil {
+			return http.HandlerFunc(route.dispatch)
+		}
+	}
+
+	return route.handler
+}
+
+// dispatch serves a route built only with Method when the method is not known
+// up front, as with Resolve, picking the handler from the request method.
+func (route *Route) dispatch(response http.ResponseWriter, request *http.Request) {
+	if handler, ok := route.handlers[strings.ToUpper(request.Method)]; ok {
+		handler.ServeHTTP(response, request)
 		return
 	}
 
-	if len(path) > 0 {
-		var tree *Router
-		var exists bool
+	var methods = append([]string(nil), route.Methods...)
+	sort.Strings(methods)
+
+	response.Header().Set("Allow", strings.Join(methods, ", "))
+	response.WriteHeader(http.StatusMethodNotAllowed)
+}
+
+func (route *Route) Use(middleware ...func(http.Handler) http.Handler) *Route {
+	route.middleware = append(route.middleware, middleware...)
+	return route
+}
+
+func (route *Route) RequireQuery(query map[string]string) *Route {
+	route.query = query
+	return route
+}
+
+func (route *Route) Defaults(defaults map[string]string) *Route {
+	route.defaults = defaults
+	return route
+}
+
+func (route *Route) Timeout(timeout time.Duration) *Route {
+	route.timeout = timeout
+	return route
+}
+
+func (route *Route) Scheme() string {
+	return route.scheme
+}
+
+func (route *Route) RequireScheme(scheme string) *Route {
+	route.scheme = strings.ToLower(scheme)
+	return route
+}
+
+func (route *Route) matches(m *match) bool {
+	if len(route.query) > 0 {
+		if m.query == nil && m.request != nil {
+			m.query = m.request.URL.Query()
+		}
+
+		for key, value := range route.query {
+			if values, ok := m.query[key]; !ok || value != "" && !contains(values, value) {
+				return false
+			}
+		}
+	}
+
+	for _, matcher := range route.matchers {
+		if m.request == nil || !matcher.Match(m.request) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (route *Route) allows(method string) bool {
+	if method == "" || len(route.Methods) == 0 {
+		return true
+	}
+
+	for _, allowed := range route.Methods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Router struct {
+	NotFoundHandler         http.Handler
+	MethodNotAllowedHandler http.Handler
+	RedirectTrailingSlash   bool
+	TrailingSlash           SlashPolicy
+	CaseInsensitive         bool
+	HandleOPTIONS           bool
+	HandleHEAD              bool
+	CleanPath               bool
+	LastResortNotFound      bool
+	DefaultTimeout          time.Duration
+	OnRequest               func(RequestStats)
+	ErrorHandler            func(http.ResponseWriter, *http.Request, error)
+
+	nodes       map[string]*Router
+	folded      map[string]string
+	names       map[string]*Route
+	routers     map[string]*Router
+	mounts      []*Router
+	routes      []*Route
+	middleware  []func(http.Handler) http.Handler
+	parameters  []string
+	constraints []string
+	name        string
+	pattern     string
+	parts       []string
+	depth       int
+	cache       *cache
+	mutex       *lock
+}
+
+func segments(path string) []string {
+	var parts = make([]string, 0)
+
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if part == "" {
+			continue
+		}
+
+		parts = append(parts, part)
+
+		if part[0] == GreedyParameterRune {
+			break
+		}
+	}
+
+	return parts
+}
+
+func strip(part string) string {
+	if len(part) > 1 && part[len(part)-1] == OptionalRune {
+		return part[:len(part)-1]
+	}
+
+	return part
+}
+
+func parameter(part string) string {
+	part = strip(part)
+
+	if index := strings.IndexRune(part[1:], ParameterRune); part[0] == ParameterRune && index != -1 {
+		return part[1 : index+1]
+	}
+
+	return part[1:]
+}
+
+func parameters(path []string) []string {
+	var names = make([]string, 0)
+
+	for _, part := range path {
+		if part[0] == ParameterRune || part[0] == GreedyParameterRune {
+			names = append(names, parameter(part))
+		}
+	}
+
+	return names
+}
+
+func constraint(part string) (string, error) {
+	if index := strings.IndexRune(part[1:], ParameterRune); index == -1 {
+		return "", nil
+	} else if name := part[index+2:]; types[name] == nil {
+		return "", fmt.Errorf("Unknown parameter type '%s' in '%s'!", name, part)
+	} else {
+		return string(ParameterRune) + name, nil
+	}
+}
+
+func (router *Router) node(path []string) *Router {
+	var node = router
+
+	for _, part := range path {
+		part = strip(part)
+		var key = part
+
+		if part[0] == ParameterRune {
+			var err error
+
+			if key, err = constraint(part); err != nil {
+				panic(err.Error())
+			}
+		} else if part[0] == GreedyParameterRune {
+			key = string(GreedyParameterRune)
+		}
+
+		if next, ok := node.nodes[key]; ok {
+			node = next
+		} else {
+			next = New()
+			next.mutex = node.mutex
+			next.depth = node.depth + 1
+			node.nodes[key] = next
+
+			if key != "" && key[0] == ParameterRune {
+				node.constraints = append(node.constraints, key)
+			} else if key != "" && key[0] != GreedyParameterRune {
+				node.fold(key)
+			}
+
+			node = next
+		}
+	}
+
+	return node
+}
+
+// fold indexes a static key by its lower case form. When keys differ only in
+// case, the one that sorts first answers case-insensitive lookups.
+func (router *Router) fold(key string) {
+	var lower = strings.ToLower(key)
+
+	if router.folded == nil {
+		router.folded = make(map[string]string)
+	}
+
+	if existing, ok := router.folded[lower]; !ok || key < existing {
+		router.folded[lower] = key
+	}
+}
+
+func (router *Router) child(part string, fold bool) (*Router, bool) {
+	if part == "" || part[0] == ParameterRune || part[0] == GreedyParameterRune {
+		return nil, false
+	} else if node, ok := router.nodes[part]; ok || !fold {
+		return node, ok
+	} else if key, ok := router.folded[strings.ToLower(part)]; ok {
+		return router.nodes[key], true
+	}
+
+	return nil, false
+}
+
+func (router *Router) parameter(key, part, rest string, m *match) bool {
+	var parameters = len(m.parameters)
+
+	if node, ok := router.nodes[key]; !ok || key != "" && !types[key[1:]].MatchString(part) {
+		return false
+	} else if m.parameters = append(m.parameters, part); node.lookup(rest, m) {
+		return true
+	}
+
+	m.parameters = m.parameters[:parameters]
+
+	return false
+}
+
+func (router *Router) lookup(path string, m *match) bool {
+	var middleware = len(m.middleware)
+	var notFound, notAllowed, onError, timeout = m.notFound, m.notAllowed, m.onError, m.timeout
+	var fold = m.fold
+
+	if router.CaseInsensitive {
+		m.fold = true
+	}
+
+	if router.NotFoundHandler != nil {
+		m.notFound = router.NotFoundHandler
+	}
+
+	if router.MethodNotAllowedHandler != nil {
+		m.notAllowed = router.MethodNotAllowedHandler
+	}
+
+	if router.ErrorHandler != nil {
+		m.onError = router.ErrorHandler
+	}
+
+	if router.DefaultTimeout > 0 {
+		m.timeout = router.DefaultTimeout
+	}
+
+	if router.NotFoundHandler != nil {
+		m.missing, m.missingTimeout = router.NotFoundHandler, m.timeout
+	}
+
+	if len(router.middleware) > 0 {
+		m.middleware = append(m.middleware, router.middleware...)
+	}
+
+	if path == "" {
+		if router.accepts(m) {
+			m.leaf = router
+			return true
+		}
+	} else {
+		var index = strings.IndexByte(path, '/')
+		var part, rest = path, ""
+
+		if index != -1 {
+			part, rest = path[:index], path[index+1:]
+		}
+
+		if node, ok := router.child(part, m.fold); ok && node.lookup(rest, m) {
+			return true
+		}
+
+		if part != "" {
+			for _, key := range router.constraints {
+				if router.parameter(key, part, rest, m) {
+					return true
+				}
+			}
+
+			if router.parameter("", part, rest, m) {
+				return true
+			}
+		}
+
+		if node, ok := router.nodes[string(GreedyParameterRune)]; ok && node.accepts(m) {
+			m.leaf = node
+			m.parameters = append(m.parameters, path)
+			return true
+		}
+	}
+
+	for _, mount := range router.mounts {
+		var names, prefixes = len(m.names), len(m.prefixes)
+		m.names = append(m.names, mount.parameters...)
+		m.prefixes = append(m.prefixes, mount.pattern)
+		m.namespaces = append(m.namespaces, mount.name)
+
+		if m.owner == nil || len(m.prefixes) > m.depth {
+			m.owner, m.depth = mount, len(m.prefixes)
+		}
+
+		if mount.lookup(path, m) {
+			return true
+		}
+
+		m.names, m.prefixes, m.namespaces = m.names[:names], m.prefixes[:prefixes], m.namespaces[:prefixes]
+	}
+
+	m.middleware = m.middleware[:middleware]
+	m.notFound, m.notAllowed, m.onError, m.timeout = notFound, notAllowed, onError, timeout
+	m.fold = fold
+
+	return false
+}
+
+// accepts reports whether a route on the leaf passes its query and request
+// constraints, so lookups keep backtracking past leaves whose routes all fail.
+// Such lookups depend on the request and are marked to bypass the cache.
+func (router *Router) accepts(m *match) bool {
+	for _, route := range router.routes {
+		if len(route.query) > 0 || len(route.matchers) > 0 {
+			m.conditional = true
+		}
+
+		if route.matches(m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// search reports misses with the NotFoundHandler of the innermost router the
+// path reached, while a match only sees the settings of the routers around it.
+func (router *Router) search(path string, m *match) bool {
+	if router.lookup(path, m) {
+		return true
+	} else if m.missing != nil {
+		m.notFound, m.timeout = m.missing, m.missingTimeout
+	}
+
+	return false
+}
+
+type match struct {
+	route          *Route
+	leaf           *Router
+	parameters     []string
+	names          []string
+	hostNames      []string
+	hostLabels     []string
+	prefixes       []string
+	namespaces     []string
+	request        *http.Request
+	query          url.Values
+	middleware     []func(http.Handler) http.Handler
+	notFound       http.Handler
+	missing        http.Handler
+	missingTimeout time.Duration
+	notAllowed     http.Handler
+	allow          []string
+	onError        func(http.ResponseWriter, *http.Request, error)
+	owner          *Router
+	depth          int
+	timeout        time.Duration
+	method         string
+	fold           bool
+	conditional    bool
+	head           bool
+	context        context.Context
+	storage        [4]string
+}
+
+func (m *match) Deadline() (time.Time, bool) {
+	return m.context.Deadline()
+}
+
+func (m *match) Done() <-chan struct{} {
+	return m.context.Done()
+}
+
+func (m *match) Err() error {
+	return m.context.Err()
+}
+
+func (m *match) Value(key interface{}) interface{} {
+	switch key {
+	case Key:
+		return m.parameters
+	case matchKey:
+		return m
+	}
+
+	return m.context.Value(key)
+}
+
+func (m *match) handler() http.Handler {
+	var handler = m.route.endpoint(m.method)
+
+	if m.route.timeout > 0 {
+		handler = Timeout(m.route.timeout)(handler)
+	} else if m.timeout > 0 {
+		handler = fallback(m.timeout, handler)
+	}
+
+	for index := len(m.route.middleware) - 1; index >= 0; index-- {
+		handler = m.route.middleware[index](handler)
+	}
+
+	return m.wrap(handler)
+}
+
+func (m *match) wrap(handler http.Handler) http.Handler {
+	for index := len(m.middleware) - 1; index >= 0; index-- {
+		handler = m.middleware[index](handler)
+	}
+
+	return handler
+}
+
+func (m *match) pattern() string {
+	var buffer bytes.Buffer
+
+	for _, prefix := range m.prefixes {
+		if prefix != "/" {
+			buffer.WriteString(prefix)
+		}
+	}
+
+	if m.route.pattern != "/" || buffer.Len() == 0 {
+		buffer.WriteString(m.route.pattern)
+	}
+
+	return buffer.String()
+}
+
+// values collects the parameters by name. Host labels and outer prefixes come
+// first, so the outermost value wins when names repeat.
+func (m *match) values() map[string]string {
+	var parameters = make(map[string]string, len(m.hostNames)+len(m.names))
+
+	for index, name := range m.hostNames {
+		if _, exists := parameters[name]; !exists {
+			parameters[name] = m.hostLabels[index]
+		}
+	}
+
+	for index, name := range m.names {
+		if _, exists := parameters[name]; !exists && index < len(m.parameters) {
+			parameters[name] = m.parameters[index]
+		}
+	}
+
+	return parameters
+}
+
+func (m *match) namespace() string {
+	return strings.Join(m.namespaces, string(ParameterRune))
+}
+
+func (m *match) remaining() string {
+	if m.route.greedy {
+		return "/" + m.parameters[len(m.parameters)-1]
+	}
+
+	return "/"
+}
+
+func (m *match) allowed() []string {
+	var methods = make([]string, 0)
+
+	for _, route := range m.leaf.routes {
+		if !route.matches(m) {
+			continue
+		}
+
+		for _, method := range route.Methods {
+			method = strings.ToUpper(method)
+
+			if !contains(methods, method) {
+				methods = append(methods, method)
+			}
+		}
+	}
+
+	sort.Strings(methods)
+
+	return methods
+}
+
+func (m *match) bind(route *Route, method string) {
+	m.route, m.method = route, method
+
+	if len(m.names) == 0 {
+		m.names = route.parameters[:len(route.parameters):len(route.parameters)]
+	} else {
+		m.names = append(m.names, route.parameters...)
+	}
+
+	for len(m.parameters) < len(m.names) {
+		m.parameters = append(m.parameters, route.defaults[m.names[len(m.parameters)]])
+	}
+}
+
+// find prefers routes that list the method over routes without methods, which
+// only catch the methods no sibling on the same path handles.
+func (m *match) find(method string) error {
+	var err = ErrNotFound
+	var fallback *Route
+
+	for _, route := range m.leaf.routes {
+		if !route.matches(m) {
+			continue
+		} else if method != "" && len(route.Methods) == 0 {
+			if fallback == nil {
+				fallback = route
+			}
+
+			continue
+		} else if route.allows(method) {
+			m.bind(route, method)
+			return nil
+		}
+
+		err = ErrMethodNotAllowed
+	}
+
+	if fallback != nil {
+		m.bind(fallback, method)
+		return nil
+	}
+
+	return err
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (router *Router) resolve(method, path string, request *http.Request) (*match, error) {
+	var m = &match{request: request}
+	m.parameters = m.storage[:0]
+
+	return m, router.into(m, method, path)
+}
+
+// into resolves path into a match owned by the caller. Resolve keeps its match
+// on the stack, so it leaves parameters nil instead of pointing them at storage.
+func (router *Router) into(m *match, method, path string) error {
+	m.fold = router.CaseInsensitive
+
+	router.mutex.RLock()
+	defer router.mutex.RUnlock()
+
+	if path != "" && path[0] == '/' {
+		path = path[1:]
+	}
+
+	var ok bool
+
+	if router.cache == nil {
+		ok = router.search(path, m)
+	} else if found, hit := router.cache.get(path, m.fold, router.mutex.version, m); hit {
+		ok = found
+	} else {
+		ok = router.search(path, m)
+
+		if !m.conditional {
+			router.cache.put(path, m.fold, router.mutex.version, ok, m)
+		}
+	}
+
+	if router.LastResortNotFound {
+		m.notFound = router.NotFoundHandler
+	}
+
+	if !ok {
+		return ErrNotFound
+	}
+
+	var err = m.find(method)
+
+	if err == ErrMethodNotAllowed && method == http.MethodHead && router.HandleHEAD {
+		if m.find(http.MethodGet) == nil {
+			err, m.head = nil, true
+		}
+	}
+
+	// The leaf and the route may change once the lock is released, so the
+	// Allow list and the owning router are taken here.
+	if err == ErrMethodNotAllowed {
+		m.allow = m.allowed()
+	} else if err == nil {
+		m.owner = m.route.router
+	}
+
+	return err
+}
+
+func (router *Router) Resolve(path string) (http.Handler, []string) {
+	var m match
+
+	if err := router.into(&m, "", path); err != nil {
+		return m.notFound, nil
+	} else if m.parameters == nil {
+		return m.route.endpoint(m.method), []string{}
+	} else {
+		return m.route.endpoint(m.method), m.parameters
+	}
+}
+
+type Resolution struct {
+	Route      *Route
+	Parameters []string
+	Namespace  string
+	Owned      bool
+}
+
+func (router *Router) namespace(target *Router) (string, bool) {
+	for name, node := range router.routers {
+		if node == target {
+			return name, true
+		} else if namespace, ok := node.namespace(target); ok {
+			return qualify(name, namespace), true
+		}
+	}
+
+	return "", false
+}
+
+func (router *Router) ResolveDetailed(method, path string) (Resolution, error) {
+	var m, err = router.resolve(method, path, nil)
+	var resolution Resolution
+
+	if err == nil {
+		resolution.Route, resolution.Parameters = m.route, m.parameters
+	}
+
+	router.mutex.RLock()
+	defer router.mutex.RUnlock()
+
+	if m.owner != nil && m.owner != router {
+		resolution.Namespace, resolution.Owned = router.namespace(m.owner)
+	}
+
+	return resolution, err
+}
+
+func (router *Router) ResolveRemaining(path string) (*Route, string, bool) {
+	if m, err := router.resolve("", path, nil); err != nil {
+		return nil, "", false
+	} else {
+		return m.route, m.remaining(), true
+	}
+}
+
+func (router *Router) ResolveMethod(method, path string) (http.Handler, []string, error) {
+	if m, err := router.resolve(method, path, nil); err != nil {
+		return nil, nil, err
+	} else {
+		return m.route.endpoint(m.method), m.parameters, nil
+	}
+}
+
+func (router *Router) ResolveRequest(request *http.Request) (http.Handler, []string, error) {
+	if m, err := router.resolve(request.Method, request.URL.Path, request); err != nil {
+		return nil, nil, err
+	} else {
+		return m.route.endpoint(m.method), m.parameters, nil
+	}
+}
+
+type RouteMatch struct {
+	Route            *Route
+	Namespace        string
+	Pattern          string
+	Parameters       map[string]string
+	MethodNotAllowed bool
+	Allowed          []string
+}
+
+// locate resolves a request the way ServeHTTP dispatches it, returning the
+// cleaned path it matched and the parameters of any router serving it.
+func (router *Router) locate(request *http.Request) (string, *match, error) {
+	var path = request.URL.Path
+
+	if router.CleanPath {
+		path = clean(path)
+	}
+
+	var m, err = router.resolve(request.Method, path, request)
+
+	if parent, ok := request.Context().Value(matchKey).(*match); ok && err == nil {
+		m.parameters = append(parent.parameters[:len(parent.parameters):len(parent.parameters)], m.parameters...)
+		m.names = append(parent.names[:len(parent.names):len(parent.names)], m.names...)
+		m.hostNames, m.hostLabels = parent.hostNames, parent.hostLabels
+	}
+
+	return path, m, err
+}
+
+func (router *Router) Match(request *http.Request) (*RouteMatch, bool) {
+	var _, m, err = router.locate(request)
+
+	switch err {
+	case nil:
+		return &RouteMatch{Route: m.route, Namespace: m.namespace(), Pattern: m.pattern(), Parameters: m.values()}, true
+	case ErrMethodNotAllowed:
+		return &RouteMatch{MethodNotAllowed: true, Allowed: m.allow}, false
+	}
+
+	return nil, false
+}
+
+func compile(path string, handler http.Handler, name string, methods []string) (*Route, error) {
+	var parts = segments(path)
+	var route = &Route{
+		Methods:    methods,
+		handler:    handler,
+		name:       name,
+		pattern:    "/" + strings.Join(parts, "/"),
+		parts:      parts,
+		parameters: parameters(parts),
+		slash:      len(parts) > 0 && strings.HasSuffix(path, "/"),
+		greedy:     len(parts) > 0 && parts[len(parts)-1][0] == GreedyParameterRune,
+		depth:      len(parts),
+		optional:   len(parts),
+	}
+
+	for index, part := range parts {
+		if strip(part) != part && route.optional == len(parts) {
+			route.optional = index
+		} else if strip(part) == part && route.optional != len(parts) {
+			return nil, fmt.Errorf("Optional segments must be trailing in '%s'!", path)
+		}
+
+		if part = strip(part); part[0] != ParameterRune && part[0] != GreedyParameterRune {
+			continue
+		} else if parameter(part) == "" {
+			return nil, fmt.Errorf("Unnamed parameter '%s' at segment %d in '%s'!", part, index+1, path)
+		} else if _, err := constraint(part); part[0] == ParameterRune && err != nil {
+			return nil, err
+		}
+	}
+
+	return route, nil
+}
+
+func (router *Router) Add(path string, handler http.Handler, name string, methods ...string) *Route {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+
+	var route, err = compile(path, handler, name, methods)
+
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return router.add(route)
+}
+
+func (router *Router) AddFunc(path string, fn func(http.ResponseWriter, *http.Request), name string, methods ...string) *Route {
+	return router.Add(path, http.HandlerFunc(fn), name, methods...)
+}
+
+func (router *Router) AddUnique(path string, handler http.Handler, name string, methods ...string) (*Route, error) {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+
+	if _, ok := router.names[name]; ok && name != "" {
+		return nil, &DuplicateError{Name: name}
+	}
+
+	var route, err = compile(path, handler, name, methods)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return router.add(route), nil
+}
+
+func (router *Router) AddRoute(route *Route) *Route {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+
+	return router.add(route)
+}
+
+func (router *Router) add(route *Route) *Route {
+	var name = route.name
+	var leaves = router.leaves(route)
+	var replaced = make(map[*Router]bool, len(leaves))
+
+	for _, leaf := range leaves {
+		replaced[leaf] = false
+	}
+
+	if previous, ok := router.names[name]; ok && name != "" {
+		for _, leaf := range router.leaves(previous) {
+			if _, ok := replaced[leaf]; ok && previous.depth == route.depth && leaf.index(previous) != -1 {
+				leaf.routes[leaf.index(previous)] = route
+				replaced[leaf] = true
+			} else {
+				leaf.remove(previous)
+			}
+		}
+
+		previous.router = nil
+	}
+
+	for _, leaf := range leaves {
+		if !replaced[leaf] {
+			leaf.insert(route)
+		}
+	}
+
+	if name != "" {
+		router.names[name] = route
+	}
+
+	route.router = router
+
+	return route
+}
+
+func (router *Router) Handle(method, path string, handler http.Handler) *Route {
+	return router.Add(path, handler, method+" /"+strings.Join(segments(path), "/"), method)
+}
+
+func (router *Router) GET(path string, handler http.Handler) *Route {
+	return router.Handle(http.MethodGet, path, handler)
+}
+
+func (router *Router) POST(path string, handler http.Handler) *Route {
+	return router.Handle(http.MethodPost, path, handler)
+}
+
+func (router *Router) PUT(path string, handler http.Handler) *Route {
+	return router.Handle(http.MethodPut, path, handler)
+}
+
+func (router *Router) PATCH(path string, handler http.Handler) *Route {
+	return router.Handle(http.MethodPatch, path, handler)
+}
+
+func (router *Router) DELETE(path string, handler http.Handler) *Route {
+	return router.Handle(http.MethodDelete, path, handler)
+}
+
+func (router *Router) leaves(route *Route) []*Router {
+	var parts = segments(route.pattern)
+	var leaves = make([]*Router, 0, len(parts)-route.optional+1)
+
+	for depth := len(parts); depth >= route.optional; depth-- {
+		leaves = append(leaves, router.node(parts[:depth]))
+	}
+
+	return leaves
+}
+
+// Prioritizer lets a handler decide where its route sits among the routes of
+// the same pattern: higher priorities are tried first, handlers that do not
+// implement it count as 0, and equal priorities keep their insertion order.
+type Prioritizer interface {
+	Priority() int
+}
+
+func priority(handler http.Handler) int {
+	if prioritizer, ok := handler.(Prioritizer); ok {
+		return prioritizer.Priority()
+	}
+
+	return 0
+}
+
+func (router *Router) precedes(route, other *Route) bool {
+	if exact := route.depth == router.depth; exact != (other.depth == router.depth) {
+		return exact
+	}
+
+	return priority(route.handler) > priority(other.handler)
+}
+
+func (router *Router) insert(route *Route) {
+	var index = len(router.routes)
+
+	for index > 0 && router.precedes(route, router.routes[index-1]) {
+		index--
+	}
+
+	router.routes = append(router.routes[:index:index], append([]*Route{route}, router.routes[index:]...)...)
+}
+
+func (router *Router) index(route *Route) int {
+	for index, r := range router.routes {
+		if r == route {
+			return index
+		}
+	}
+
+	return -1
+}
+
+func (router *Router) remove(route *Route) bool {
+	if index := router.index(route); index != -1 {
+		router.routes = append(router.routes[:index:index], router.routes[index+1:]...)
+		return true
+	}
+
+	return false
+}
+
+func (router *Router) AddRouter(prefix string, node *Router, namespace string) {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+
+	var parts = segments(prefix)
+
+	if previous, ok := router.routers[namespace]; ok {
+		var position = router.node(previous.parts)
+
+		for index, mount := range position.mounts {
+			if mount == previous {
+				position.mounts = append(position.mounts[:index:index], position.mounts[index+1:]...)
+				break
+			}
+		}
+	}
+
+	node.name = namespace
+	node.pattern = "/" + strings.Join(parts, "/")
+	node.parts = parts
+	node.parameters = parameters(parts)
+
+	var position = router.node(parts)
+	position.mounts = append(position.mounts, node)
+	node.share(router.mutex)
+
+	router.routers[namespace] = node
+}
+
+func (router *Router) Group(prefix, namespace string, middleware ...func(http.Handler) http.Handler) *Router {
+	var node = New()
+	node.Use(middleware...)
+
+	router.AddRouter(prefix, node, namespace)
+
+	return node
+}
+
+func (router *Router) Remove(name string) bool {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+
+	return router.unregister(name)
+}
+
+func (router *Router) unregister(name string) bool {
+	if route, ok := router.names[name]; ok {
+		var removed = false
+
+		delete(router.names, name)
+		route.router = nil
+
+		for _, leaf := range router.leaves(route) {
+			removed = leaf.remove(route) || removed
+		}
+
+		return removed
+	} else if node, ok := router.routers[name]; ok {
+		var position = router.node(segments(node.pattern))
+
+		for index, mount := range position.mounts {
+			if mount == node {
+				position.mounts = append(position.mounts[:index:index], position.mounts[index+1:]...)
+				break
+			}
+		}
+
+		delete(router.routers, name)
+		return true
+	} else if index := strings.IndexRune(name, ':'); index != -1 {
+		if node, ok := router.routers[name[:index]]; ok {
+			return node.unregister(name[index+1:])
+		}
+	}
+
+	return false
+}
+
+func (router *Router) Walk(fn func(namespace string, route *Route) error) error {
+	router.mutex.RLock()
+	defer router.mutex.RUnlock()
+
+	return router.walk("", fn)
+}
+
+func (router *Router) walk(namespace string, fn func(namespace string, route *Route) error) error {
+	for _, route := range router.routes {
+		if route.depth != router.depth {
+			continue
+		} else if err := fn(namespace, route); err != nil {
+			return err
+		}
+	}
+
+	var keys = make([]string, 0, len(router.nodes))
+
+	for key := range router.nodes {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := router.nodes[key].walk(namespace, fn); err != nil {
+			return err
+		}
+	}
+
+	for _, mount := range router.mounts {
+		var name = mount.name
+
+		if namespace != "" {
+			name = fmt.Sprintf("%s:%s", namespace, mount.name)
+		}
+
+		if err := mount.walk(name, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type Conflict struct {
+	Pattern string
+	First   string
+	Second  string
+}
+
+func (conflict Conflict) Error() string {
+	return fmt.Sprintf("Route '%s' shadows '%s' at %s!", conflict.First, conflict.Second, conflict.Pattern)
+}
 
-		if tree, exists = router.nodes[path[0]]; !exists {
-			tree = New()
-			tree.parameterized = parameterized
-			tree.greedy = greedy
-			tree.parameters = append(router.parameters, node.parameters...)
+func (route *Route) shadows(other *Route) bool {
+	if len(route.matchers) > 0 {
+		return false
+	}
 
-			router.nodes[path[0]] = tree
-			router.names[name] = tree.pattern
+	for key, value := range route.query {
+		if v, ok := other.query[key]; !ok || value != "" && v != value {
+			return false
 		}
+	}
 
-		if len(path) > 1 {
-			tree.update(path[1:], node, name)
-		} else if exists {
-			tree.parameterized = parameterized
-			tree.greedy = greedy
-			tree.parameters = node.parameters
-		} else {
-			node.parameterized = parameterized
-			node.greedy = greedy
-			node.parameters = append(router.parameters, node.parameters...)
-			router.nodes[path[0]] = node
-			router.names[name] = node.pattern
+	if len(route.Methods) == 0 && len(other.Methods) == 0 {
+		return true
+	} else if len(route.Methods) == 0 || len(other.Methods) == 0 {
+		return false
+	}
 
-			tree = node
+	for _, method := range route.Methods {
+		if other.allows(method) {
+			return true
 		}
+	}
 
-		for name, pattern := range tree.names {
-			if !tree.route && tree.name != "" {
-				name = fmt.Sprintf("%s:%s", tree.name, name)
-			}
+	return false
+}
 
-			if !tree.route && tree.pattern != "" {
-				pattern = strings.Trim(pattern, "/")
-				pattern = fmt.Sprintf("%s/%s", tree.pattern, pattern)
-			}
+func join(prefix, pattern string) string {
+	if prefix == "" || prefix == "/" {
+		return pattern
+	} else if pattern == "/" {
+		return prefix
+	}
+
+	return prefix + pattern
+}
 
-			router.names[name] = pattern
+func qualify(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+
+	return fmt.Sprintf("%s:%s", namespace, name)
+}
+
+func (router *Router) Validate() []Conflict {
+	var conflicts = make([]Conflict, 0)
+
+	router.mutex.RLock()
+	defer router.mutex.RUnlock()
+
+	router.validate("", "", &conflicts)
+
+	return conflicts
+}
+
+func (router *Router) validate(namespace, prefix string, conflicts *[]Conflict) {
+	for index, route := range router.routes {
+		for _, other := range router.routes[index+1:] {
+			if route.depth == router.depth && other.depth == router.depth && route.shadows(other) {
+				*conflicts = append(*conflicts, Conflict{
+					Pattern: join(prefix, route.pattern),
+					First:   qualify(namespace, route.name),
+					Second:  qualify(namespace, other.name),
+				})
+			}
 		}
 	}
+
+	var keys = make([]string, 0, len(router.nodes))
+
+	for key := range router.nodes {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		router.nodes[key].validate(namespace, prefix, conflicts)
+	}
+
+	for _, mount := range router.mounts {
+		mount.validate(qualify(namespace, mount.name), join(prefix, mount.pattern), conflicts)
+	}
 }
 
-func (router *Router) Resolve(path string) (http.Handler, []string) {
-	var parameters = make([]string, 0)
-	var node = router
+type NamedRoute struct {
+	Name    string
+	Pattern string
+}
 
-	if path[0] == '/' {
-		path = path[1:]
+func (router *Router) ReverseAll() []NamedRoute {
+	var routes = make([]NamedRoute, 0)
+
+	router.mutex.RLock()
+	defer router.mutex.RUnlock()
+
+	router.reversible("", "", &routes)
+
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Name < routes[j].Name
+	})
+
+	return routes
+}
+
+func (router *Router) reversible(namespace, prefix string, routes *[]NamedRoute) {
+	for name, route := range router.names {
+		*routes = append(*routes, NamedRoute{Name: qualify(namespace, name), Pattern: join(prefix, route.pattern)})
+	}
+
+	for name, node := range router.routers {
+		node.reversible(qualify(namespace, name), join(prefix, node.pattern), routes)
+	}
+}
+
+// Clone deep-copies the router, its nodes, mounted routers and routes, so
+// routes can be added, removed, renamed or given metadata on the clone alone.
+// Handlers, middleware functions, matchers and metadata values are shared.
+func (router *Router) Clone() *Router {
+	router.mutex.RLock()
+	defer router.mutex.RUnlock()
+
+	var clone = router.clone(&lock{}, make(map[*Router]*Router), make(map[*Route]*Route))
+
+	if router.cache != nil {
+		clone.cache = newCache(router.cache.size)
 	}
 
-	for path != "" {
-		var index = strings.IndexRune(path, '/')
-		var part string
+	return clone
+}
+
+func (router *Router) clone(mutex *lock, routers map[*Router]*Router, routes map[*Route]*Route) *Router {
+	var clone = *router
+	routers[router] = &clone
+
+	clone.mutex = mutex
+	clone.cache = nil
+	clone.nodes = make(map[string]*Router, len(router.nodes))
+	clone.folded = make(map[string]string, len(router.folded))
+	clone.names = make(map[string]*Route, len(router.names))
+	clone.routers = make(map[string]*Router, len(router.routers))
+	clone.mounts = make([]*Router, len(router.mounts))
+	clone.routes = make([]*Route, len(router.routes))
+	clone.middleware = append([]func(http.Handler) http.Handler(nil), router.middleware...)
+	clone.constraints = append([]string(nil), router.constraints...)
 
-		if index == -1 {
-			part = path
-			path = ""
-		} else if node.greedy {
-			parameters = append(parameters, path)
-			return node.handler, parameters
+	for index, route := range router.routes {
+		if duplicate, ok := routes[route]; ok {
+			clone.routes[index] = duplicate
 		} else {
-			part = path[:index]
-			path = path[index+1:]
+			var duplicate = *route
+			duplicate.Methods = append([]string(nil), route.Methods...)
+			duplicate.middleware = append([]func(http.Handler) http.Handler(nil), route.middleware...)
+			duplicate.matchers = append([]Matcher(nil), route.matchers...)
+			duplicate.router = routers[route.router]
+
+			routes[route] = &duplicate
+			clone.routes[index] = &duplicate
+		}
+	}
+
+	for key, node := range router.nodes {
+		clone.nodes[key] = node.clone(mutex, routers, routes)
+	}
+
+	for lower, key := range router.folded {
+		clone.folded[lower] = key
+	}
+
+	for index, mount := range router.mounts {
+		clone.mounts[index] = mount.clone(mutex, routers, routes)
+	}
+
+	for name, route := range router.names {
+		clone.names[name] = routes[route]
+	}
+
+	for namespace, node := range router.routers {
+		clone.routers[namespace] = routers[node]
+	}
+
+	return &clone
+}
+
+type DuplicateError struct {
+	Name string
+}
+
+func (err *DuplicateError) Error() string {
+	return fmt.Sprintf("Duplicate name '%s'!", err.Name)
+}
+
+func (router *Router) Merge(other *Router) error {
+	var source = other.Clone()
+
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+
+	for name := range source.names {
+		if _, ok := router.names[name]; ok && name != "" {
+			return &DuplicateError{Name: name}
+		}
+	}
+
+	for namespace := range source.routers {
+		if _, ok := router.routers[namespace]; ok {
+			return &DuplicateError{Name: namespace}
+		}
+	}
+
+	source.merge(router, source.middleware)
+
+	for namespace, node := range source.routers {
+		node.middleware = append(source.middleware[:len(source.middleware):len(source.middleware)], node.middleware...)
+		node.share(router.mutex)
+
+		router.node(node.parts).mounts = append(router.node(node.parts).mounts, node)
+		router.routers[namespace] = node
+	}
+
+	return nil
+}
+
+func (router *Router) merge(target *Router, middleware []func(http.Handler) http.Handler) {
+	for _, route := range router.routes {
+		if route.depth == router.depth {
+			route.middleware = append(middleware[:len(middleware):len(middleware)], route.middleware...)
+			target.add(route)
 		}
+	}
+
+	for _, node := range router.nodes {
+		node.merge(target, middleware)
+	}
+}
+
+type RouteInfo struct {
+	Name      string                 `json:"name,omitempty"`
+	Namespace string                 `json:"namespace,omitempty"`
+	Prefix    string                 `json:"prefix"`
+	Pattern   string                 `json:"pattern"`
+	Methods   []string               `json:"methods,omitempty"`
+	Meta      map[string]interface{} `json:"meta,omitempty"`
+}
+
+func (router *Router) Describe() []RouteInfo {
+	var infos = make([]RouteInfo, 0)
+
+	router.mutex.RLock()
+	defer router.mutex.RUnlock()
 
-		if nextNode, ok := node.nodes[part]; ok {
-			node = nextNode
+	router.describe("", "/", &infos)
+
+	return infos
+}
+
+func (router *Router) describe(namespace, prefix string, infos *[]RouteInfo) {
+	for _, route := range router.routes {
+		if route.depth != router.depth {
 			continue
-		} else if nextNode, ok := node.nodes[""]; ok && nextNode.parameterized {
-			node = nextNode
-			parameters = append(parameters, part)
+		}
+
+		var info = RouteInfo{
+			Namespace: namespace,
+			Prefix:    prefix,
+			Pattern:   join(prefix, route.pattern),
+			Methods:   route.Methods,
+			Meta:      route.meta,
+		}
+
+		if route.name != "" {
+			info.Name = qualify(namespace, route.name)
+		}
+
+		*infos = append(*infos, info)
+	}
+
+	var keys = make([]string, 0, len(router.nodes))
+
+	for key := range router.nodes {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		router.nodes[key].describe(namespace, prefix, infos)
+	}
+
+	for _, mount := range router.mounts {
+		mount.describe(qualify(namespace, mount.name), join(prefix, mount.pattern), infos)
+	}
+}
+
+func (router *Router) MarshalJSON() ([]byte, error) {
+	return json.Marshal(router.Describe())
+}
+
+func (router *Router) share(mutex *lock) {
+	router.mutex = mutex
+
+	for _, node := range router.nodes {
+		node.share(mutex)
+	}
+
+	for _, mount := range router.mounts {
+		mount.share(mutex)
+	}
+}
+
+func (router *Router) Use(middleware ...func(http.Handler) http.Handler) {
+	router.mutex.Lock()
+	defer router.mutex.Unlock()
+
+	router.middleware = append(router.middleware, middleware...)
+}
+
+func (router *Router) reverse(buffer *strings.Builder, name string, parameters []string) ([]string, error) {
+	var parts []string
+	var defaults map[string]string
+	var node *Router
+
+	if route, ok := router.names[name]; ok {
+		parts, defaults = route.parts, route.defaults
+	} else if index := strings.IndexRune(name, ':'); index == -1 {
+		return nil, ErrNameNotFound
+	} else if node, ok = router.routers[name[:index]]; !ok {
+		return nil, ErrNamespaceNotFound
+	} else {
+		parts = node.parts
+		name = name[index+1:]
+	}
+
+	var pending []string
+
+	for _, part := range parts {
+		var optional = strip(part) != part
+
+		if part = strip(part); part[0] != ParameterRune && part[0] != GreedyParameterRune {
+			if optional {
+				pending = append(pending, part)
+			} else {
+				buffer.WriteRune('/')
+				buffer.WriteString(part)
+			}
+
 			continue
-		} else if node.NotFoundHandler != nil {
-			return node.NotFoundHandler, nil
+		}
+
+		var value string
+
+		if len(parameters) > 0 {
+			value, parameters = parameters[0], parameters[1:]
+		}
+
+		if value == "" {
+			value = defaults[parameter(part)]
+		}
+
+		if value == "" && !optional {
+			return nil, &MissingParameterError{Parameter: parameter(part)}
+		} else if value == "" {
+			break
+		}
+
+		for _, static := range pending {
+			buffer.WriteRune('/')
+			buffer.WriteString(static)
+		}
+
+		pending = pending[:0]
+		buffer.WriteRune('/')
+
+		if part[0] == ParameterRune {
+			buffer.WriteString(url.PathEscape(value))
 		} else {
-			return nil, nil
+			buffer.WriteString(strings.ReplaceAll(url.PathEscape(value), "%2F", "/"))
 		}
 	}
 
-	if node.handler != nil {
-		return node.handler, parameters
+	if node != nil {
+		return node.reverse(buffer, name, parameters)
 	}
 
-	return nil, nil
+	return parameters, nil
 }
 
-func (router *Router) Add(path string, handler http.Handler, name string) {
-	var node = New()
-	node.name = name
-	node.handler = handler
-	node.pattern = fmt.Sprintf("/%s", strings.Trim(path, "/"))
-	node.route = true
+func (router *Router) Reverse(name string, parameters ...string) (string, error) {
+	var buffer strings.Builder
+	var size = 32
 
-	router.update(strings.Split(strings.Trim(path, "/"), "/"), node, name)
+	for _, parameter := range parameters {
+		size += len(parameter) + 1
+	}
+
+	buffer.Grow(size)
+
+	router.mutex.RLock()
+	defer router.mutex.RUnlock()
+
+	if _, err := router.reverse(&buffer, name, parameters); err != nil {
+		return "", err
+	} else if buffer.Len() == 0 {
+		return "/", nil
+	} else if !router.RedirectTrailingSlash && router.TrailingSlash == SlashAsRegistered {
+		return buffer.String(), nil
+	} else if route := router.named(name); route != nil && !route.greedy && router.slash(route) {
+		buffer.WriteRune('/')
+	}
+
+	return buffer.String(), nil
 }
 
-func (router *Router) AddRouter(prefix string, node *Router, namespace string) {
-	node.name = namespace
-	node.pattern = fmt.Sprintf("/%s", strings.Trim(prefix, "/"))
+func (router *Router) ReverseURL(name string, base *url.URL, parameters ...string) (string, error) {
+	var path, err = router.Reverse(name, parameters...)
+
+	if err != nil {
+		return "", err
+	}
+
+	var location = url.URL{Scheme: base.Scheme, User: base.User, Host: base.Host}
+	location.RawPath = strings.TrimRight(base.EscapedPath(), "/") + path
+
+	if location.Path, err = url.PathUnescape(location.RawPath); err != nil {
+		return "", err
+	}
+
+	router.mutex.RLock()
+	defer router.mutex.RUnlock()
+
+	if route := router.named(name); route != nil && route.scheme != "" {
+		location.Scheme = route.scheme
+	}
 
-	router.update(strings.Split(strings.Trim(prefix, "/"), "/"), node, namespace)
+	return location.String(), nil
 }
 
-func (router *Router) Reverse(name string, parameters ...string) (string, error) {
-	var buffer bytes.Buffer
+func (router *Router) named(name string) *Route {
+	if route, ok := router.names[name]; ok {
+		return route
+	} else if index := strings.IndexRune(name, ':'); index == -1 {
+		return nil
+	} else if node, ok := router.routers[name[:index]]; ok {
+		return node.named(name[index+1:])
+	}
+
+	return nil
+}
+
+func (router *Router) ReverseQuery(name string, query map[string]string, parameters ...string) (string, error) {
+	var values = make(url.Values, len(query))
+
+	for key, value := range query {
+		values.Set(key, value)
+	}
+
+	return router.ReverseValues(name, values, parameters...)
+}
+
+func (router *Router) ReverseValues(name string, query url.Values, parameters ...string) (string, error) {
+	if path, err := router.Reverse(name, parameters...); err != nil {
+		return "", err
+	} else if len(query) > 0 {
+		return path + "?" + query.Encode(), nil
+	} else {
+		return path, nil
+	}
+}
+
+func clean(location string) string {
+	var cleaned = path.Clean("/" + location)
+
+	if cleaned != "/" && strings.HasSuffix(location, "/") {
+		cleaned = cleaned + "/"
+	}
+
+	return cleaned
+}
+
+type SlashPolicy int
+
+const (
+	SlashAsRegistered SlashPolicy = iota
+	SlashAlways
+	SlashNever
+)
 
-	if path, ok := router.names[name]; !ok {
-		return "", errors.New("Name not found!")
+func (router *Router) slash(route *Route) bool {
+	switch router.TrailingSlash {
+	case SlashAlways:
+		return true
+	case SlashNever:
+		return false
+	}
+
+	return route.slash
+}
+
+func scheme(request *http.Request) string {
+	if request.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+func (router *Router) canonical(m *match, path string, request *http.Request) (string, bool) {
+	var slash = strings.HasSuffix(path, "/")
+
+	if !router.RedirectTrailingSlash || path == "/" || m.route.greedy || slash == router.slash(m.route) {
+		return "", false
+	}
+
+	if slash {
+		path = strings.TrimRight(path, "/")
 	} else {
-		if path[0] == '/' {
-			path = path[1:]
+		path = path + "/"
+	}
+
+	if request.URL.RawQuery != "" {
+		path = path + "?" + request.URL.RawQuery
+	}
+
+	return path, true
+}
+
+type RequestStats struct {
+	Method   string
+	Pattern  string
+	Status   int
+	Bytes    int
+	Duration time.Duration
+}
+
+func (router *Router) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	if router.OnRequest == nil {
+		router.serve(response, request)
+		return
+	}
+
+	var recorder = &responseRecorder{ResponseWriter: response}
+	var start = time.Now()
+	var m = router.serve(recorder, request)
+	var stats = RequestStats{
+		Method:   request.Method,
+		Status:   recorder.Status(),
+		Bytes:    recorder.bytes,
+		Duration: time.Since(start),
+	}
+
+	if m.route != nil {
+		stats.Pattern = m.pattern()
+	}
+
+	router.OnRequest(stats)
+}
+
+func (router *Router) serve(response http.ResponseWriter, request *http.Request) *match {
+	var path, m, err = router.locate(request)
+
+	switch err {
+	case nil:
+		var location, ok = router.canonical(m, path, request)
+
+		if !ok && path != request.URL.Path && (request.Method == http.MethodGet || request.Method == http.MethodHead) {
+			location, ok = path, true
+
+			if request.URL.RawQuery != "" {
+				location = location + "?" + request.URL.RawQuery
+			}
 		}
 
-		for path != "" {
-			buffer.WriteRune('/')
+		var secure = m.route.scheme != "" && m.route.scheme != scheme(request)
 
-			var index = strings.IndexRune(path, '/')
-			var part string
+		if secure && !ok {
+			location, ok = request.URL.RequestURI(), true
+		}
 
-			if index == -1 {
-				part = path
-				path = ""
-			} else {
-				part = path[:index]
-				path = path[index+1:]
+		if ok {
+			location = stripped(request) + location
+		}
+
+		if secure {
+			location = m.route.scheme + "://" + request.Host + location
+		}
+
+		if ok {
+			var status = http.StatusPermanentRedirect
+
+			if request.Method == http.MethodGet || request.Method == http.MethodHead {
+				status = http.StatusMovedPermanently
 			}
 
-			if part[0] == ParameterRune || part[0] == GreedyParameterRune {
-				buffer.WriteString(parameters[0])
+			http.Redirect(response, request, location, status)
+			return m
+		}
+
+		m.context = request.Context()
 
-				if part[0] == GreedyParameterRune {
-					break
-				}
+		if m.head {
+			response = &headWriter{ResponseWriter: response}
+		}
+
+		m.handler().ServeHTTP(response, request.WithContext(m))
+	case ErrMethodNotAllowed:
+		m.wrap(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			var allowed = m.allow
+
+			if router.HandleHEAD && contains(allowed, http.MethodGet) && !contains(allowed, http.MethodHead) {
+				allowed = append(allowed, http.MethodHead)
+				sort.Strings(allowed)
+			}
 
-				parameters = parameters[1:]
+			if router.HandleOPTIONS && request.Method == http.MethodOptions {
+				allowed = append(allowed, http.MethodOptions)
+				sort.Strings(allowed)
+
+				response.Header().Set("Allow", strings.Join(allowed, ", "))
+				response.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			response.Header().Set("Allow", strings.Join(allowed, ", "))
+
+			if m.notAllowed != nil {
+				m.notAllowed.ServeHTTP(response, request)
 			} else {
-				buffer.WriteString(part)
+				response.WriteHeader(http.StatusMethodNotAllowed)
 			}
+		})).ServeHTTP(response, request)
+	default:
+		if m.notFound != nil && m.timeout > 0 {
+			fallback(m.timeout, m.notFound).ServeHTTP(response, request)
+		} else if m.notFound != nil {
+			m.notFound.ServeHTTP(response, request)
+		} else {
+			response.WriteHeader(http.StatusNotFound)
 		}
 	}
 
-	return buffer.String(), nil
+	return m
 }
 
-func (router *Router) ServeHTTP(response http.ResponseWriter, request *http.Request) {
-	if handler, parameters := router.Resolve(request.URL.Path); handler != nil {
-		var ctx = context.WithValue(request.Context(), Key, parameters)
-
-		handler.ServeHTTP(response, request.WithContext(ctx))
+func notFound(response http.ResponseWriter, request *http.Request) {
+	if m, ok := request.Context().Value(matchKey).(*match); ok && m.notFound != nil {
+		m.notFound.ServeHTTP(response, request)
 	} else {
 		response.WriteHeader(http.StatusNotFound)
 	}
 }
 
-func New() *Router {
-	return &Router{
-		nodes: make(map[string]*Router),
-		names: make(map[string]string),
+// Error renders err with the ErrorHandler of the innermost router around the
+// matched route, falling back to a 500 with the error text. Recover does not call
+// it on its own; pass an onPanic that does to render panics the same way.
+func Error(response http.ResponseWriter, request *http.Request, err error) {
+	if m, ok := request.Context().Value(matchKey).(*match); ok && m.onError != nil {
+		m.onError(response, request, err)
+	} else {
+		http.Error(response, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func New(options ...Option) *Router {
+	var router = &Router{
+		nodes:   make(map[string]*Router),
+		names:   make(map[string]*Route),
+		routers: make(map[string]*Router),
+		mutex:   &lock{},
+	}
+
+	for _, option := range options {
+		option(router)
 	}
+
+	return router
 }
@@ -1,12 +1,21 @@
 package routes
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 )
 
-type Handler struct{}
+type Handler struct {
+	name string
+}
 
 func (handler *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {}
 
@@ -29,7 +38,7 @@ func TestRouterResolve(test *testing.T) {
 	}
 	var tests = map[string]Result{
 		"/1/activity/2":          Result{true, []string{"1", "2"}},
-		"api/v1/user":            Result{false, nil},
+		"api/v1/user":            Result{true, []string{"user"}},
 		"/api/v1/user/1":         Result{true, []string{"1"}},
 		"api/v2/user":            Result{false, nil},
 		"/static/path/to/static": Result{true, []string{"path/to/static"}},
@@ -48,6 +57,31 @@ func TestRouterResolve(test *testing.T) {
 	}
 }
 
+func TestRouterStaticBacktracking(test *testing.T) {
+	var router = New()
+	router.Add("/users/me", &Handler{name: "me"}, "me")
+	router.Add("/users/:id/posts", &Handler{name: "posts"}, "posts")
+	router.Add("/users/me/settings", &Handler{name: "settings"}, "settings")
+
+	var tests = map[string]struct {
+		name       string
+		parameters []string
+	}{
+		"/users/me":          {"me", []string{}},
+		"/users/me/posts":    {"posts", []string{"me"}},
+		"/users/me/settings": {"settings", []string{}},
+		"/users/1/posts":     {"posts", []string{"1"}},
+	}
+
+	for path, expected := range tests {
+		if handler, parameters := router.Resolve(path); handler != router.named(expected.name).handler {
+			test.Fatalf("Expected %s for %s, got %v", expected.name, path, handler)
+		} else if !reflect.DeepEqual(parameters, expected.parameters) {
+			test.Fatalf("Expected %v for %s, got %v", expected.parameters, path, parameters)
+		}
+	}
+}
+
 func TestRouterReverse(test *testing.T) {
 	var api = New()
 	api.Add("/:name/endpoint/:id", &Handler{}, "endpoint")
@@ -64,97 +98,2211 @@ func TestRouterReverse(test *testing.T) {
 	}
 }
 
-func TestRouterNotFoundHandler(test *testing.T) {
+func TestRouterReverseQuery(test *testing.T) {
+	var router = New()
+	router.Add("/users/:id", &Handler{}, "users:detail")
+
+	if path, err := router.ReverseQuery("users:detail", map[string]string{"tab": "billing", "q": "a b&c"}, "5"); err != nil {
+		test.Fatal(err)
+	} else if path != "/users/5?q=a+b%26c&tab=billing" {
+		test.Fatal(path)
+	}
+
+	if path, err := router.ReverseQuery("users:detail", nil, "5"); err != nil {
+		test.Fatal(err)
+	} else if path != "/users/5" {
+		test.Fatal(path)
+	}
+}
+
+func TestRouterReverseValues(test *testing.T) {
+	var router = New()
+	router.Add("/search/:type", &Handler{}, "search")
+
+	var query = url.Values{"tag": {"b", "a"}, "page": {"2"}}
+
+	for i := 0; i < 10; i++ {
+		if path, err := router.ReverseValues("search", query, "posts"); err != nil {
+			test.Fatal(err)
+		} else if path != "/search/posts?page=2&tag=b&tag=a" {
+			test.Fatal(path)
+		}
+	}
+
+	if _, err := router.ReverseValues("search", query); err == nil {
+		test.Fatal("Expected error for missing parameter!")
+	}
+}
+
+func TestRouterReverseEscape(test *testing.T) {
+	var router = New()
+	router.Add("/users/:name", &Handler{}, "user")
+	router.Add("/static/*path", &Handler{}, "static")
+
+	var tests = map[string][]string{
+		"/users/a%2Fb%20c":           {"user", "a/b c"},
+		"/users/john":                {"user", "john"},
+		"/static/css/my%20style.css": {"static", "css/my style.css"},
+	}
+
+	for expected, arguments := range tests {
+		if path, err := router.Reverse(arguments[0], arguments[1]); err != nil {
+			test.Fatal(err)
+		} else if path != expected {
+			test.Errorf("Expected: %s", expected)
+			test.Fatalf("Got: %s", path)
+		}
+	}
+}
+
+func TestRouterReverseNested(test *testing.T) {
+	var users = New()
+	users.Add("/:id/", &Handler{}, "detail")
+
+	var v1 = New()
+	v1.AddRouter("//users//", users, "users")
+
 	var api = New()
-	api.NotFoundHandler = &Handler{}
+	api.AddRouter("", v1, "v1")
 
 	var router = New()
-	router.AddRouter("api", api, "api")
+	router.AddRouter("/api/", api, "api")
 
-	if handler, _ := router.Resolve("/api/v1/path"); handler == nil {
+	if path, err := router.Reverse("api:v1:users:detail", "5"); err != nil {
+		test.Fatal(err)
+	} else if path != "/api/users/5" {
+		test.Fatal(path)
+	}
+
+	if handler, parameters := router.Resolve("/api/users/5"); handler == nil || parameters[0] != "5" {
 		test.Fatal("Expected handler!")
 	}
 }
 
-func TestOverrideRoutes(test *testing.T) {
+func TestRouterReverseNestedPrefixes(test *testing.T) {
+	var tests = []struct {
+		prefixes [3]string
+		pattern  string
+		expected string
+	}{
+		{[3]string{"/a", "", "/c"}, "/:id", "/a/c/5"},
+		{[3]string{"/a/", "/", "//c//"}, "/:id", "/a/c/5"},
+		{[3]string{"", "", ""}, "/:id", "/5"},
+		{[3]string{"/a", "", "/"}, "/", "/a"},
+		{[3]string{"", "/b", ""}, "/items/:id", "/b/items/5"},
+	}
+
+	for _, t := range tests {
+		var c = New()
+		c.Add(t.pattern, &Handler{}, "d")
+
+		var b = New()
+		b.AddRouter(t.prefixes[2], c, "c")
+
+		var a = New()
+		a.AddRouter(t.prefixes[1], b, "b")
+
+		var router = New()
+		router.AddRouter(t.prefixes[0], a, "a")
+
+		var parameters []string
+
+		if t.pattern != "/" {
+			parameters = []string{"5"}
+		}
+
+		if path, err := router.Reverse("a:b:c:d", parameters...); err != nil || path != t.expected {
+			test.Fatalf("Expected %s for %v, got %s (%v)", t.expected, t.prefixes, path, err)
+		} else if handler, _ := router.Resolve(path); handler == nil {
+			test.Fatalf("Expected %s to resolve!", path)
+		}
+	}
+}
+
+func TestRouterReplaceNamespace(test *testing.T) {
 	var v1 = New()
+	v1.Add("/users/:id", &Handler{}, "user")
+	v1.Add("/legacy", &Handler{}, "legacy")
+
+	var v2 = New()
+	v2.Add("/users/:id", &Handler{}, "user")
+
+	var router = New()
+	router.AddRouter("/v1", v1, "api")
+	router.AddRouter("/v2", v2, "api")
+
+	if path, err := router.Reverse("api:user", "1"); err != nil || path != "/v2/users/1" {
+		test.Fatalf("Unexpected path: %s (%v)", path, err)
+	} else if _, err := router.Reverse("api:legacy"); err != ErrNameNotFound {
+		test.Fatalf("Expected %v, got %v", ErrNameNotFound, err)
+	} else if handler, _ := router.Resolve("/v1/users/1"); handler != nil {
+		test.Fatal("Expected the replaced router to be unmounted!")
+	} else if handler, _ := router.Resolve("/v2/users/1"); handler == nil {
+		test.Fatal("Expected handler!")
+	}
+}
+
+func TestRouterRootPrefix(test *testing.T) {
+	for prefix, base := range map[string]string{"": "", "/": "", "/api": "/api", "/api/": "/api"} {
+		var served string
+		var handler = func(name string) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				served = name + ":" + Param(r.Context(), "name")
+			})
+		}
+
+		var inner = New()
+		inner.Add("/", handler("root"), "root")
+		inner.Add("/:name", handler("page"), "page")
+
+		var router = New()
+		router.AddRouter(prefix, inner, "site")
+
+		var root = base
+
+		if root == "" {
+			root = "/"
+		}
+
+		var tests = map[string]string{
+			root:                "root:",
+			base + "/anything":  "page:anything",
+			base + "/anything/": "page:anything",
+			base + "/a/b":       "",
+		}
+
+		for path, expected := range tests {
+			served = ""
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+
+			if served != expected {
+				test.Fatalf("Expected %q for %s under %q, got %q", expected, path, prefix, served)
+			}
+		}
+
+		if path, err := router.Reverse("site:root"); err != nil || path != root {
+			test.Fatalf("Expected %s under %q, got %s (%v)", root, prefix, path, err)
+		} else if path, err := router.Reverse("site:page", "anything"); err != nil || path != base+"/anything" {
+			test.Fatalf("Expected %s/anything under %q, got %s (%v)", base, prefix, path, err)
+		}
+	}
+}
+
+func TestRouterAnchored(test *testing.T) {
+	for name, expression := range types {
+		if pattern := expression.String(); !strings.HasPrefix(pattern, "^") || !strings.HasSuffix(pattern, "$") {
+			test.Fatalf("Expected parameter type %s to be anchored, got %s", name, pattern)
+		}
+	}
+
+	var router = New()
+	router.Add("/users/:id", &Handler{}, "user")
+	router.Add("/posts/:id:int", &Handler{}, "post")
+	router.Add("/tags/:tag:slug", &Handler{}, "tag")
+
+	var tests = map[string]bool{
+		"/users/5":          true,
+		"/users/5/extra":    false,
+		"/prefix/users/5":   false,
+		"/posts/5":          true,
+		"/posts/5abc":       false,
+		"/posts/abc5":       false,
+		"/tags/go-routes":   true,
+		"/tags/go-routes/x": false,
+		"/tags/Go routes":   false,
+	}
+
+	for path, expected := range tests {
+		if handler, _ := router.Resolve(path); (handler != nil) != expected {
+			test.Fatalf("Expected match %v for %s", expected, path)
+		}
+	}
+}
+
+func TestRouterExactMatch(test *testing.T) {
+	var served string
+	var handler = func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served = name + ":" + Param(r.Context(), "id") + Param(r.Context(), "path")
+		})
+	}
+
+	var router = New(WithRedirectTrailingSlash(), WithTrailingSlash(SlashNever))
+	router.Add("/users/:id", handler("user"), "user")
+	router.Add("/users/:id/posts", handler("posts"), "posts")
+	router.Add("/files/*path", handler("files"), "files")
+
+	var tests = []struct {
+		path     string
+		status   int
+		expected string
+	}{
+		{"/users/5", http.StatusOK, "user:5"},
+		{"/users/5/posts", http.StatusOK, "posts:5"},
+		{"/users/5/profile", http.StatusNotFound, ""},
+		{"/users/5/", http.StatusMovedPermanently, ""},
+		{"/files/a", http.StatusOK, "files:a"},
+		{"/files/a/b/c.txt", http.StatusOK, "files:a/b/c.txt"},
+		{"/files/a/b/", http.StatusOK, "files:a/b/"},
+		{"/files", http.StatusNotFound, ""},
+	}
+
+	for _, t := range tests {
+		var response = httptest.NewRecorder()
+		served = ""
+		router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, t.path, nil))
+
+		if response.Code != t.status || served != t.expected {
+			test.Fatalf("Expected %d %q for %s, got %d %q", t.status, t.expected, t.path, response.Code, served)
+		}
+	}
+}
+
+func TestRouterReverseErrors(test *testing.T) {
 	var api = New()
-	var root = New()
+	api.Add("/users/:id", &Handler{}, "user")
 
-	v1.Add("/path/add", &Handler{}, "path.add")
-	v1.Add("/path", &Handler{}, "path")
-	v1.Add("/path/:id", &Handler{}, "path.id")
-	v1.Add("/path/new", &Handler{}, "path.new")
+	var router = New()
+	router.AddRouter("/api", api, "api")
 
-	api.AddRouter("/v1", v1, "v1")
-	root.AddRouter("/api", api, "api")
+	if _, err := router.Reverse("missing"); err != ErrNameNotFound {
+		test.Fatalf("Expected %v, got %v", ErrNameNotFound, err)
+	}
 
-	if handler, _ := root.Resolve("/api/v1/path/add"); handler == nil {
-		test.Fatal("/api/v1/path/add not found!")
+	if _, err := router.Reverse("v2:user", "1"); err != ErrNamespaceNotFound {
+		test.Fatalf("Expected %v, got %v", ErrNamespaceNotFound, err)
 	}
 
-	if handler, _ := root.Resolve("/api/v1/path/new"); handler == nil {
-		test.Fatal("/api/v1/path/new not found!")
+	if _, err := router.Reverse("api:missing"); err != ErrNameNotFound {
+		test.Fatalf("Expected %v, got %v", ErrNameNotFound, err)
 	}
 
-	if path, err := root.Reverse("api:v1:path.id", "1"); err != nil {
+	var users = New()
+	users.Add("/:id", &Handler{}, "detail")
+	api.AddRouter("/accounts", users, "accounts")
+
+	for name, expected := range map[string]error{
+		"api:accounts:missing":   ErrNameNotFound,
+		"api:accounts:detail:id": ErrNamespaceNotFound,
+		"api:v2:detail":          ErrNamespaceNotFound,
+	} {
+		if path, err := router.Reverse(name, "1"); err != expected || path != "" {
+			test.Fatalf("Expected %v for %s, got %q (%v)", expected, name, path, err)
+		}
+	}
+
+	if path, err := router.ReverseValues("api:accounts:missing", url.Values{"page": {"2"}}, "1"); err != ErrNameNotFound || path != "" {
+		test.Fatalf("Expected %v, got %q (%v)", ErrNameNotFound, path, err)
+	}
+
+	var missing *MissingParameterError
+
+	if _, err := router.Reverse("api:user"); !errors.As(err, &missing) || missing.Parameter != "id" {
+		test.Fatalf("Expected missing parameter error, got %v", err)
+	} else if err.Error() != "Missing parameter 'id'!" {
 		test.Fatal(err)
-	} else if path != "/api/v1/path/1" {
-		test.Fatal(path)
 	}
 }
 
-func BenchmarkRouterTwoParameters(benchmark *testing.B) {
+func TestRouterMatch(test *testing.T) {
+	var called bool
+	var handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	var api = New()
+	api.Add("/users/:id", handler, "user", http.MethodGet, http.MethodPut)
+
 	var router = New()
+	router.AddRouter("/:version", api, "api")
 
-	router.Add(":user/activity/:activity", &Handler{}, "")
+	var m, ok = router.Match(httptest.NewRequest(http.MethodGet, "/v1/users/5", nil))
 
-	for i := 0; i < benchmark.N; i++ {
-		router.Resolve("/1/activity/2")
+	if !ok || m.Route != api.named("user") || m.Pattern != "/:version/users/:id" {
+		test.Fatalf("Unexpected match: %+v", m)
+	} else if expected := map[string]string{"version": "v1", "id": "5"}; !reflect.DeepEqual(m.Parameters, expected) {
+		test.Fatalf("Expected %v, got %v", expected, m.Parameters)
+	} else if called {
+		test.Fatal("Expected Match not to call the handler!")
+	}
+
+	m, ok = router.Match(httptest.NewRequest(http.MethodPost, "/v1/users/5", nil))
+
+	if ok || !m.MethodNotAllowed || !reflect.DeepEqual(m.Allowed, []string{http.MethodGet, http.MethodPut}) {
+		test.Fatalf("Unexpected match: %+v", m)
+	}
+
+	if m, ok = router.Match(httptest.NewRequest(http.MethodGet, "/v1/missing", nil)); ok || m != nil {
+		test.Fatalf("Unexpected match: %+v", m)
+	}
+
+	var params map[string]string
+	var tenant = New()
+	tenant.Add("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params = Params(r.Context())
+	}), "user")
+
+	var outer = New()
+	outer.AddRouter("/:id", tenant, "tenant")
+
+	var request = httptest.NewRequest(http.MethodGet, "/acme/users/5", nil)
+	outer.ServeHTTP(httptest.NewRecorder(), request)
+
+	if m, ok = outer.Match(request); !ok || m.Parameters["id"] != "acme" {
+		test.Fatalf("Expected the outer id to win, got %+v", m)
+	} else if !reflect.DeepEqual(m.Parameters, params) {
+		test.Fatalf("Expected %v to match Params %v", m.Parameters, params)
+	}
+
+	var inner = New()
+	inner.Add("/posts/:post", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params = Params(r.Context())
+		m, _ = inner.Match(r)
+	}), "post")
+
+	var site = New()
+	site.Mount("/:site", inner)
+	site.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/blog/posts/7", nil))
+
+	if m == nil || m.Parameters["site"] != "blog" || m.Parameters["post"] != "7" {
+		test.Fatalf("Expected the mount parameters, got %+v", m)
+	} else if !reflect.DeepEqual(m.Parameters, params) {
+		test.Fatalf("Expected %v to match Params %v", m.Parameters, params)
 	}
 }
 
-func BenchmarkRouterGreedy(benchmark *testing.B) {
+func TestRouterDefaults(test *testing.T) {
 	var router = New()
+	router.Add("/posts/:page?", &Handler{}, "posts").Defaults(map[string]string{"page": "1"})
+	router.Add("/feed/:format", &Handler{}, "feed").Defaults(map[string]string{"format": "json"})
 
-	router.Add("static/*path", &Handler{}, "")
+	var tests = []struct {
+		name       string
+		parameters []string
+		expected   string
+	}{
+		{"posts", nil, "/posts/1"},
+		{"posts", []string{""}, "/posts/1"},
+		{"posts", []string{"3"}, "/posts/3"},
+		{"feed", nil, "/feed/json"},
+		{"feed", []string{"xml"}, "/feed/xml"},
+	}
 
-	for i := 0; i < benchmark.N; i++ {
-		router.Resolve("/static/path/to/static")
+	for _, t := range tests {
+		if path, err := router.Reverse(t.name, t.parameters...); err != nil || path != t.expected {
+			test.Fatalf("Expected %s, got %s (%v)", t.expected, path, err)
+		}
+	}
+
+	if _, parameters := router.Resolve("/posts"); !reflect.DeepEqual(parameters, []string{"1"}) {
+		test.Fatalf("Expected default parameter, got %v", parameters)
+	} else if _, parameters := router.Resolve("/posts/2"); !reflect.DeepEqual(parameters, []string{"2"}) {
+		test.Fatalf("Expected captured parameter, got %v", parameters)
 	}
 }
 
-func BenchmarkRouterStatic(benchmark *testing.B) {
+func TestRouteMethod(test *testing.T) {
+	var served string
+	var handler = func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served = name + ":" + Param(r.Context(), "id")
+		})
+	}
+
+	var router = New(WithHandleHEAD())
+	router.Add("/users/:id", nil, "user").Method(http.MethodGet, handler("get")).Method("delete", handler("delete"))
+
+	for method, expected := range map[string]string{
+		http.MethodGet:    "get:1",
+		http.MethodHead:   "get:1",
+		http.MethodDelete: "delete:1",
+	} {
+		served = ""
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/users/1", nil))
+
+		if served != expected {
+			test.Fatalf("Expected %s for %s, got %s", expected, method, served)
+		}
+	}
+
+	var response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodPut, "/users/1", nil))
+
+	if response.Code != http.StatusMethodNotAllowed || response.Header().Get("Allow") != "DELETE, GET, HEAD" {
+		test.Fatalf("Unexpected response: %d %s", response.Code, response.Header().Get("Allow"))
+	} else if path, err := router.Reverse("user", "1"); err != nil || path != "/users/1" {
+		test.Fatalf("Unexpected path: %s", path)
+	} else if handler, _, _ := router.ResolveMethod(http.MethodDelete, "/users/2"); handler == nil {
+		test.Fatal("Expected the DELETE handler!")
+	}
+
+	var resolved, parameters = router.Resolve("/users/3")
+
+	if resolved == nil || !reflect.DeepEqual(parameters, []string{"3"}) {
+		test.Fatalf("Expected a handler for a Method-only route, got %v", parameters)
+	}
+
+	served = ""
+	resolved.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/3", nil))
+
+	if served != "get:" {
+		test.Fatalf("Expected the GET handler, got %s", served)
+	}
+
+	response = httptest.NewRecorder()
+	resolved.ServeHTTP(response, httptest.NewRequest(http.MethodPut, "/users/3", nil))
+
+	if response.Code != http.StatusMethodNotAllowed || response.Header().Get("Allow") != "DELETE, GET" {
+		test.Fatalf("Unexpected response: %d %s", response.Code, response.Header().Get("Allow"))
+	}
+
+	router.Add("/any", handler("any"), "any").Method(http.MethodDelete, handler("delete"))
+
+	for method, expected := range map[string]string{
+		http.MethodGet:    "any:",
+		http.MethodPost:   "any:",
+		http.MethodDelete: "delete:",
+	} {
+		response, served = httptest.NewRecorder(), ""
+		router.ServeHTTP(response, httptest.NewRequest(method, "/any", nil))
+
+		if response.Code != http.StatusOK || served != expected {
+			test.Fatalf("Expected %s for %s, got %d %s", expected, method, response.Code, served)
+		}
+	}
+}
+
+func TestRouterAnyMethod(test *testing.T) {
+	var served string
+	var handler = func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served = name
+		})
+	}
+
 	var router = New()
+	router.Add("/proxy", handler("any"), "any")
+	router.Add("/proxy", handler("get"), "get", http.MethodGet)
 
-	router.Add("/some/static/path", &Handler{}, "")
-	router.Add("/some/static2/path", &Handler{}, "")
-	router.Add("/some/static/path2", &Handler{}, "")
+	for method, expected := range map[string]string{
+		http.MethodGet:    "get",
+		http.MethodPost:   "any",
+		http.MethodDelete: "any",
+	} {
+		served = ""
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/proxy", nil))
 
-	for i := 0; i < benchmark.N; i++ {
-		router.Resolve("/some/static/path")
+		if served != expected {
+			test.Fatalf("Expected %s for %s, got %s", expected, method, served)
+		}
 	}
 }
 
-func BenchmarkRouterReverse(benchmark *testing.B) {
+func TestRouterResolveDetailed(test *testing.T) {
+	var users = New()
+	users.Add("/:id", &Handler{}, "user", http.MethodGet)
+
 	var api = New()
-	api.Add("/:name/endpoint/:id", &Handler{}, "endpoint")
+	api.AddRouter("/users", users, "users")
+	api.Add("/status", &Handler{}, "status")
 
 	var router = New()
-	router.AddRouter("api", api, "api")
+	router.AddRouter("/api", api, "api")
+	router.Add("/", &Handler{}, "home")
 
-	var parameters = []string{"n", "1"}
+	var tests = []struct {
+		method    string
+		path      string
+		route     string
+		namespace string
+		owned     bool
+		err       error
+	}{
+		{http.MethodGet, "/", "home", "", false, nil},
+		{http.MethodGet, "/api/status", "status", "api", true, nil},
+		{http.MethodGet, "/api/users/1", "user", "api:users", true, nil},
+		{http.MethodGet, "/api/missing", "", "api", true, ErrNotFound},
+		{http.MethodGet, "/api/users/1/posts", "", "api:users", true, ErrNotFound},
+		{http.MethodPost, "/api/users/1", "", "api:users", true, ErrMethodNotAllowed},
+		{http.MethodGet, "/other", "", "", false, ErrNotFound},
+	}
 
-	for i := 0; i < benchmark.N; i++ {
-		router.Reverse("api:endpoint", parameters...)
+	for _, t := range tests {
+		var resolution, err = router.ResolveDetailed(t.method, t.path)
+		var name string
+
+		if resolution.Route != nil {
+			name = resolution.Route.Name()
+		}
+
+		if err != t.err || name != t.route || resolution.Namespace != t.namespace || resolution.Owned != t.owned {
+			test.Fatalf("Unexpected resolution for %s %s: %+v (%v)", t.method, t.path, resolution, err)
+		}
 	}
 }
 
-func BenchmarkRouterServeHTTP(benchmark *testing.B) {
-	var request, _ = http.NewRequest("GET", "/1/activity/2", nil)
+func TestRouterResolveRemaining(test *testing.T) {
+	var files = New()
+	files.Add("/*path", &Handler{}, "files")
+
 	var router = New()
+	router.Add("/users/:id", &Handler{}, "user")
+	router.AddRouter("/static", files, "static")
 
-	router.Add(":user/activity/:activity", &Handler{}, "")
+	for path, expected := range map[string]string{
+		"/users/1":            "/",
+		"/static/css/app.css": "/css/app.css",
+		"/static/css/":        "/css/",
+	} {
+		if route, remaining, ok := router.ResolveRemaining(path); !ok || route == nil {
+			test.Fatalf("Expected route for %s", path)
+		} else if remaining != expected {
+			test.Fatalf("Expected %s, got %s", expected, remaining)
+		}
+	}
+
+	if _, _, ok := router.ResolveRemaining("/missing"); ok {
+		test.Fatal("Expected no match!")
+	}
+}
+
+func TestRouterHandle(test *testing.T) {
+	var router = New()
+	router.GET("/users/:id", &Handler{})
+	router.POST("users", &Handler{}).Named("users.create")
+	router.DELETE("/users/:id", &Handler{}).SetMeta("scope", "admin")
+
+	if _, _, err := router.ResolveMethod(http.MethodPut, "/users/1"); err != ErrMethodNotAllowed {
+		test.Fatalf("Expected %v, got %v", ErrMethodNotAllowed, err)
+	} else if _, _, err := router.ResolveMethod(http.MethodDelete, "/users/1"); err != nil {
+		test.Fatal(err)
+	}
+
+	for name, expected := range map[string]string{
+		"GET /users/:id": "/users/1",
+		"users.create":   "/users",
+	} {
+		if path, err := router.Reverse(name, "1"); err != nil {
+			test.Fatal(err)
+		} else if path != expected {
+			test.Fatalf("Expected %s, got %s", expected, path)
+		}
+	}
+
+	if _, err := router.Reverse("POST /users"); err != ErrNameNotFound {
+		test.Fatalf("Expected %v, got %v", ErrNameNotFound, err)
+	}
+}
+
+func TestRouterScheme(test *testing.T) {
+	var api = New()
+	api.Add("/login", &Handler{}, "login").RequireScheme("HTTPS")
+	api.Add("/users/:id", &Handler{}, "user")
+
+	var router = New()
+	router.AddRouter("/api", api, "api")
+
+	var base, _ = url.Parse("http://example.com/base/")
+
+	for name, expected := range map[string]string{
+		"api:login": "https://example.com/base/api/login",
+		"api:user":  "http://example.com/base/api/users/a%2Fb",
+	} {
+		if location, err := router.ReverseURL(name, base, "a/b"); err != nil {
+			test.Fatal(err)
+		} else if location != expected {
+			test.Fatalf("Expected %s, got %s", expected, location)
+		}
+	}
+
+	if path, _ := router.Reverse("api:login"); path != "/api/login" {
+		test.Fatalf("Expected relative path, got %s", path)
+	}
+
+	var tests = []struct {
+		method, target string
+		tls            bool
+		status         int
+		location       string
+	}{
+		{http.MethodGet, "http://example.com/api/login?next=/", false, http.StatusMovedPermanently, "https://example.com/api/login?next=/"},
+		{http.MethodPost, "http://example.com/api/login", false, http.StatusPermanentRedirect, "https://example.com/api/login"},
+		{http.MethodGet, "https://example.com/api/login", true, http.StatusOK, ""},
+		{http.MethodGet, "http://example.com/api/users/1", false, http.StatusOK, ""},
+	}
+
+	for _, t := range tests {
+		var request = httptest.NewRequest(t.method, t.target, nil)
+
+		if !t.tls {
+			request.TLS = nil
+		}
+
+		var response = httptest.NewRecorder()
+		router.ServeHTTP(response, request)
+
+		if response.Code != t.status || response.Header().Get("Location") != t.location {
+			test.Errorf("Test '%s %s' failed!", t.method, t.target)
+			test.Fatalf("Got: %d %s", response.Code, response.Header().Get("Location"))
+		}
+	}
+}
+
+func TestRouterReverseAll(test *testing.T) {
+	var v1 = New()
+	v1.Add("/users/:id", &Handler{}, "user")
+	v1.Add("/", &Handler{}, "index")
+
+	var api = New()
+	api.AddRouter("/v1", v1, "v1")
+	api.Add("/health", &Handler{}, "health")
+	api.Add("/anonymous", &Handler{}, "")
+
+	var router = New()
+	router.AddRouter("/:tenant", api, "api")
+	router.Add("/", &Handler{}, "home")
+
+	var expected = []NamedRoute{
+		{Name: "api:health", Pattern: "/:tenant/health"},
+		{Name: "api:v1:index", Pattern: "/:tenant/v1"},
+		{Name: "api:v1:user", Pattern: "/:tenant/v1/users/:id"},
+		{Name: "home", Pattern: "/"},
+	}
+
+	if routes := router.ReverseAll(); !reflect.DeepEqual(routes, expected) {
+		test.Fatalf("Expected %v, got %v", expected, routes)
+	}
+
+	for _, route := range expected {
+		if _, err := router.Reverse(route.Name, "acme", "1"); err != nil {
+			test.Fatal(err)
+		}
+	}
+}
+
+func TestRouteParameter(test *testing.T) {
+	var router = New()
+	var post = router.Add("/users/:id:int/posts/:post/:page?", &Handler{}, "post")
+	var files = router.Add("/files/*path", &Handler{}, "files")
+
+	var tests = []struct {
+		route      *Route
+		path, name string
+		value      string
+		found      bool
+	}{
+		{post, "/users/5/posts/hello", "post", "hello", true},
+		{post, "/users/5/posts/hello/2", "page", "2", true},
+		{post, "/users/5/posts/hello", "page", "", false},
+		{post, "/users/5/posts/hello", "missing", "", false},
+		{post, "/users/abc/posts/hello", "post", "", false},
+		{post, "/users/5/comments/hello", "id", "", false},
+		{post, "/users/5/posts/hello/2/extra", "id", "", false},
+		{files, "/files/css/app.css", "path", "css/app.css", true},
+	}
+
+	for _, t := range tests {
+		if value, found := t.route.Parameter(t.path, t.name); value != t.value || found != t.found {
+			test.Fatalf("Expected %q %v for %s %s, got %q %v", t.value, t.found, t.path, t.name, value, found)
+		}
+	}
+}
+
+func TestRouterReversePlaceholders(test *testing.T) {
+	var router = New()
+	router.Add("/users/:id/posts/:post", &Handler{}, "post")
+
+	for _, parameters := range [][]string{{}, {"1"}, {"1", ""}} {
+		if path, err := router.Reverse("post", parameters...); err == nil {
+			test.Fatalf("Expected error for %v, got %s", parameters, path)
+		}
+	}
+
+	if path, err := router.Reverse("post", "1", "2", "extra"); err != nil {
+		test.Fatal(err)
+	} else if path != "/users/1/posts/2" {
+		test.Fatal(path)
+	}
+}
+
+func TestRouterOptionalSegments(test *testing.T) {
+	var router = New()
+	var post = &Handler{}
+	var comments = &Handler{}
+
+	router.Add("/posts/:id/comments?/:page:int?", comments, "comments")
+	router.Add("/posts/:id", post, "post")
+
+	for path, expected := range map[string][]string{
+		"/posts/1":              {"1", ""},
+		"/posts/1/comments":     {"1", ""},
+		"/posts/1/comments/2":   {"1", "2"},
+		"/posts/1/comments/two": nil,
+	} {
+		var handler, parameters = router.Resolve(path)
+
+		if expected == nil {
+			if handler != nil {
+				test.Fatalf("Expected no handler for %s", path)
+			}
+		} else if path == "/posts/1" && handler != post {
+			test.Fatalf("Expected static route to win for %s", path)
+		} else if path != "/posts/1" && handler != comments {
+			test.Fatalf("Expected optional route for %s", path)
+		} else if path != "/posts/1" && !reflect.DeepEqual(parameters, expected) {
+			test.Fatalf("Expected %v, got %v", expected, parameters)
+		}
+	}
+
+	for expected, parameters := range map[string][]string{
+		"/posts/1":            {"1"},
+		"/posts/1/comments/2": {"1", "2"},
+	} {
+		if path, err := router.Reverse("comments", parameters...); err != nil {
+			test.Fatal(err)
+		} else if path != expected {
+			test.Fatalf("Expected %s, got %s", expected, path)
+		}
+	}
+
+	if !router.Remove("comments") {
+		test.Fatal("Expected route to be removed!")
+	} else if handler, _ := router.Resolve("/posts/1/comments"); handler != nil {
+		test.Fatal("Expected optional registrations to be removed!")
+	}
+}
+
+func TestRouterNotFoundHandler(test *testing.T) {
+	var api = New()
+	api.NotFoundHandler = &Handler{}
+
+	var router = New()
+	router.AddRouter("api", api, "api")
+
+	if handler, _ := router.Resolve("/api/v1/path"); handler == nil {
+		test.Fatal("Expected handler!")
+	}
+}
+
+func TestRouterNotFoundPrecedence(test *testing.T) {
+	var handler = func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(body))
+		})
+	}
+
+	var v1 = New()
+	v1.Add("/users", &Handler{}, "users")
+
+	var api = New()
+	api.NotFoundHandler = handler("json")
+	api.AddRouter("/v1", v1, "v1")
+
+	var router = New()
+	router.NotFoundHandler = handler("html")
+	router.AddRouter("/api", api, "api")
+
+	var tests = map[string]string{
+		"/missing":         "html",
+		"/api/missing":     "json",
+		"/api/v1/missing":  "json",
+		"/apis/v1/missing": "html",
+	}
+
+	for path, expected := range tests {
+		var response = httptest.NewRecorder()
+		router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if response.Code != http.StatusNotFound || response.Body.String() != expected {
+			test.Fatalf("Expected %s for %s, got %d %q", expected, path, response.Code, response.Body.String())
+		}
+	}
+}
+
+func TestRouterErrorHandler(test *testing.T) {
+	var failing = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Error(w, r, errors.New("Broken!"))
+	})
+
+	var api = New()
+	api.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	api.Add("/users", failing, "users")
+
+	var router = New()
+	router.Add("/broken", failing, "broken")
+	router.AddRouter("/api", api, "api")
+
+	var response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/broken", nil))
+
+	if response.Code != http.StatusInternalServerError {
+		test.Fatalf("Expected %d, got %d", http.StatusInternalServerError, response.Code)
+	} else if body := response.Body.String(); body != "Broken!\n" {
+		test.Fatalf("Unexpected body: %q", body)
+	}
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+
+	if response.Code != http.StatusBadGateway {
+		test.Fatalf("Expected %d, got %d", http.StatusBadGateway, response.Code)
+	}
+}
+
+func TestRouterErrorHandlerFailedMount(test *testing.T) {
+	var api = New()
+	api.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		w.WriteHeader(http.StatusTeapot)
+	}
+	api.Add("/users", &Handler{}, "users")
+
+	var router = New()
+	router.AddRouter("/api", api, "api")
+	router.Add("/*rest", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Error(w, r, errors.New("Broken!"))
+	}), "rest")
+
+	var response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/api/missing", nil))
+
+	if response.Code != http.StatusInternalServerError {
+		test.Fatalf("Expected %d, got %d", http.StatusInternalServerError, response.Code)
+	}
+}
+
+func TestRouterLastResortNotFound(test *testing.T) {
+	var inner, outer = &Handler{}, &Handler{}
+
+	var api = New()
+	api.NotFoundHandler = inner
+	api.Add("/users", &Handler{}, "users")
+
+	var router = New()
+	router.NotFoundHandler = outer
+	router.AddRouter("/api", api, "api")
+
+	if handler, _ := router.Resolve("/api/missing"); handler != inner {
+		test.Fatal("Expected nested not found handler!")
+	}
+
+	router.LastResortNotFound = true
+
+	if handler, _ := router.Resolve("/api/missing"); handler != outer {
+		test.Fatal("Expected top-level not found handler!")
+	} else if handler, _ := router.Resolve("/api/users"); handler == outer {
+		test.Fatal("Expected route handler!")
+	}
+}
+
+func TestRouterOnRequest(test *testing.T) {
+	var stats []RequestStats
+
+	var api = New()
+	api.Add("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("user"))
+	}), "user", http.MethodGet)
+
+	var router = New()
+	router.OnRequest = func(s RequestStats) {
+		stats = append(stats, s)
+	}
+	router.AddRouter("/api", api, "api")
+
+	for _, path := range []string{"/api/users/1", "/api/users/2", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/users/1", nil))
+
+	var expected = []RequestStats{
+		{Method: http.MethodGet, Pattern: "/api/users/:id", Status: http.StatusOK, Bytes: 4},
+		{Method: http.MethodGet, Pattern: "/api/users/:id", Status: http.StatusOK, Bytes: 4},
+		{Method: http.MethodGet, Pattern: "", Status: http.StatusNotFound},
+		{Method: http.MethodPost, Pattern: "", Status: http.StatusMethodNotAllowed},
+	}
+
+	if len(stats) != len(expected) {
+		test.Fatalf("Expected %d entries, got %d", len(expected), len(stats))
+	}
+
+	for index := range expected {
+		stats[index].Duration = 0
+
+		if stats[index] != expected[index] {
+			test.Fatalf("Expected %v, got %v", expected[index], stats[index])
+		}
+	}
+}
+
+func TestRouterServeNotFound(test *testing.T) {
+	var router = New()
+	router.Add("/users", &Handler{}, "users")
+
+	var response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	if response.Code != http.StatusNotFound {
+		test.Fatalf("Expected %d, got %d", http.StatusNotFound, response.Code)
+	}
+
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/users/1" || r.Context().Value(Key) != nil {
+			test.Error("Expected original request!")
+		}
+
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	if response.Code != http.StatusTeapot {
+		test.Fatalf("Expected %d, got %d", http.StatusTeapot, response.Code)
+	}
+}
+
+func TestRouterPrefixBoundary(test *testing.T) {
+	var api, apiv2 = New(), New()
+	var users, usersv2 = &Handler{}, &Handler{}
+
+	api.Add("/users", users, "users")
+	apiv2.Add("/users", usersv2, "users")
+
+	var router = New()
+	router.AddRouter("/api", api, "api")
+	router.AddRouter("/apiv2", apiv2, "apiv2")
+
+	var tests = map[string]http.Handler{
+		"/api/users":    users,
+		"/apiv2/users":  usersv2,
+		"/apixyz/users": nil,
+		"/api":          nil,
+	}
+
+	for path, expected := range tests {
+		if handler, _ := router.Resolve(path); handler != expected {
+			test.Fatalf("Test '%s' failed!", path)
+		}
+	}
+}
+
+func TestOverrideRoutes(test *testing.T) {
+	var v1 = New()
+	var api = New()
+	var root = New()
+
+	v1.Add("/path/add", &Handler{}, "path.add")
+	v1.Add("/path", &Handler{}, "path")
+	v1.Add("/path/:id", &Handler{}, "path.id")
+	v1.Add("/path/new", &Handler{}, "path.new")
+
+	api.AddRouter("/v1", v1, "v1")
+	root.AddRouter("/api", api, "api")
+
+	if handler, _ := root.Resolve("/api/v1/path/add"); handler == nil {
+		test.Fatal("/api/v1/path/add not found!")
+	}
+
+	if handler, _ := root.Resolve("/api/v1/path/new"); handler == nil {
+		test.Fatal("/api/v1/path/new not found!")
+	}
+
+	if path, err := root.Reverse("api:v1:path.id", "1"); err != nil {
+		test.Fatal(err)
+	} else if path != "/api/v1/path/1" {
+		test.Fatal(path)
+	}
+}
+
+func TestRouterMethods(test *testing.T) {
+	var get, post = &Handler{}, &Handler{}
+	var router = New()
+
+	router.Add("/users", get, "users.list", http.MethodGet)
+	router.Add("/users", post, "users.create", http.MethodPost)
+	router.Add("/any", &Handler{}, "any")
+
+	if handler, _, err := router.ResolveMethod(http.MethodGet, "/users"); err != nil || handler != get {
+		test.Fatal("Expected GET handler!")
+	}
+
+	if handler, _, err := router.ResolveMethod(http.MethodPost, "/users"); err != nil || handler != post {
+		test.Fatal("Expected POST handler!")
+	}
+
+	if _, _, err := router.ResolveMethod(http.MethodDelete, "/users"); err != ErrMethodNotAllowed {
+		test.Fatalf("Expected %v, got %v", ErrMethodNotAllowed, err)
+	}
+
+	if _, _, err := router.ResolveMethod(http.MethodGet, "/missing"); err != ErrNotFound {
+		test.Fatalf("Expected %v, got %v", ErrNotFound, err)
+	}
+
+	if _, _, err := router.ResolveMethod(http.MethodDelete, "/any"); err != nil {
+		test.Fatal(err)
+	}
+}
+
+func TestRouterResolveRequest(test *testing.T) {
+	var get, image = &Handler{}, &Handler{}
+	var router = New()
+
+	router.Add("/search", image, "search.image", http.MethodGet).RequireQuery(map[string]string{"type": "image"})
+	router.Add("/search", get, "search", http.MethodGet)
+
+	if handler, _, err := router.ResolveRequest(httptest.NewRequest(http.MethodGet, "/search?type=image", nil)); err != nil || handler != image {
+		test.Fatal("Expected image handler!")
+	}
+
+	if handler, _, err := router.ResolveRequest(httptest.NewRequest(http.MethodGet, "/search", nil)); err != nil || handler != get {
+		test.Fatal("Expected GET handler!")
+	}
+
+	if handler, _ := router.Resolve("/search"); handler != get {
+		test.Fatal("Expected path-only resolution to skip query constraints!")
+	}
+
+	if _, _, err := router.ResolveRequest(httptest.NewRequest(http.MethodPut, "/search", nil)); err != ErrMethodNotAllowed {
+		test.Fatalf("Expected %v, got %v", ErrMethodNotAllowed, err)
+	}
+}
+
+func TestRouterMethodNotAllowed(test *testing.T) {
+	var router = New()
+
+	router.Add("/users", &Handler{}, "users.list", http.MethodGet)
+	router.Add("/users", &Handler{}, "users.create", http.MethodPost)
+	router.Add("/users", &Handler{}, "users.options", http.MethodOptions)
+
+	var response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodDelete, "/users", nil))
+
+	if response.Code != http.StatusMethodNotAllowed {
+		test.Fatalf("Expected %d, got %d", http.StatusMethodNotAllowed, response.Code)
+	} else if allow := response.Header().Get("Allow"); allow != "GET, OPTIONS, POST" {
+		test.Fatalf("Unexpected Allow header: %s", allow)
+	}
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodOptions, "/users", nil))
+
+	if response.Code != http.StatusOK {
+		test.Fatalf("Expected %d, got %d", http.StatusOK, response.Code)
+	}
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	if response.Code != http.StatusNotFound {
+		test.Fatalf("Expected %d, got %d", http.StatusNotFound, response.Code)
+	}
+}
+
+func TestRouterDeterministic(test *testing.T) {
+	var static, parameter, greedy, replaced = &Handler{}, &Handler{}, &Handler{}, &Handler{}
+	var router = New()
+
+	router.Add("/files/*path", greedy, "files.greedy")
+	router.Add("/files/:id", parameter, "files.id")
+	router.Add("/files/latest", static, "files.latest")
+	router.Add("/files/latest", &Handler{}, "files.shadowed")
+
+	var tests = map[string]http.Handler{
+		"/files/latest":  static,
+		"/files/1":       parameter,
+		"/files/1/2/3":   greedy,
+		"/files/latest/": static,
+	}
+
+	for i := 0; i < 1000; i++ {
+		for path, expected := range tests {
+			if handler, _ := router.Resolve(path); handler != expected {
+				test.Fatalf("Test '%s' failed at iteration %d!", path, i)
+			}
+		}
+	}
+
+	router.Add("/files/latest", replaced, "files.latest")
+
+	if handler, _ := router.Resolve("/files/latest"); handler != replaced {
+		test.Fatal("Expected replaced handler!")
+	}
+}
+
+func TestRouterMiddleware(test *testing.T) {
+	var calls = make([]string, 0)
+	var middleware = func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name+":"+r.Context().Value(Key).([]string)[0])
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	var api = New()
+	api.Use(middleware("api"))
+	api.Add("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	}), "user")
+
+	var router = New()
+	router.Use(middleware("outer"), middleware("inner"))
+	router.AddRouter("/api", api, "api")
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/1", nil))
+
+	if expected := []string{"outer:1", "inner:1", "api:1", "handler"}; !reflect.DeepEqual(calls, expected) {
+		test.Error("Incorrect middleware order!")
+		test.Errorf("Expected: %v", expected)
+		test.Fatalf("Got: %v", calls)
+	}
+}
+
+func TestRouteMiddleware(test *testing.T) {
+	var calls = make([]string, 0)
+	var middleware = func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	var router = New()
+	router.Use(middleware("router"))
+	router.Add("/login", &Handler{}, "login").Use(middleware("route"))
+	router.Add("/logout", &Handler{}, "logout")
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/login", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/logout", nil))
+
+	if expected := []string{"router", "route", "router"}; !reflect.DeepEqual(calls, expected) {
+		test.Error("Incorrect middleware order!")
+		test.Errorf("Expected: %v", expected)
+		test.Fatalf("Got: %v", calls)
+	}
+}
+
+func TestRouterConcurrentAdd(test *testing.T) {
+	var api = New()
+	var router = New()
+	var group sync.WaitGroup
+
+	router.AddRouter("/api", api, "api")
+	api.Add("/users", &Handler{}, "users", http.MethodGet)
+
+	for i := 0; i < 4; i++ {
+		group.Add(2)
+
+		go func(i int) {
+			defer group.Done()
+
+			for j := 0; j < 100; j++ {
+				api.Add(fmt.Sprintf("/%d/%d", i, j), &Handler{}, fmt.Sprintf("%d.%d", i, j))
+				api.Add("/users", &Handler{}, fmt.Sprintf("users.%d.%d", i, j), http.MethodPut)
+			}
+		}(i)
+
+		go func(i int) {
+			defer group.Done()
+
+			for j := 0; j < 100; j++ {
+				router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/%d/%d", i, j), nil))
+				router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/users", nil))
+				router.Match(httptest.NewRequest(http.MethodPost, "/api/users", nil))
+				router.ResolveDetailed(http.MethodGet, fmt.Sprintf("/api/%d/%d", i, j))
+				router.Reverse(fmt.Sprintf("api:%d.%d", i, j))
+			}
+		}(i)
+	}
+
+	group.Wait()
+
+	if handler, _ := router.Resolve("/api/3/99"); handler == nil {
+		test.Fatal("Expected handler!")
+	}
+
+	var response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodPost, "/api/users", nil))
+
+	if allow := response.Header().Get("Allow"); response.Code != http.StatusMethodNotAllowed || allow != "GET, PUT" {
+		test.Fatalf("Expected %d with GET, PUT, got %d with %s", http.StatusMethodNotAllowed, response.Code, allow)
+	}
+}
+
+func TestRouterRemove(test *testing.T) {
+	var api = New()
+	api.Add("/users", &Handler{}, "users")
+	api.Add("/posts", &Handler{}, "posts")
+
+	var router = New()
+	router.Add("/health", &Handler{}, "health")
+	router.AddRouter("/api", api, "api")
+
+	for _, path := range []string{"/health", "/api/users", "/api/posts"} {
+		if handler, _ := router.Resolve(path); handler == nil {
+			test.Fatalf("%s not found!", path)
+		}
+	}
+
+	for _, name := range []string{"health", "api:users"} {
+		if !router.Remove(name) {
+			test.Fatalf("Expected %s to be removed!", name)
+		}
+	}
+
+	if router.Remove("health") || router.Remove("api:missing") {
+		test.Fatal("Expected nothing to be removed!")
+	}
+
+	for _, path := range []string{"/health", "/api/users"} {
+		var response = httptest.NewRecorder()
+		router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if response.Code != http.StatusNotFound {
+			test.Fatalf("Expected %d for %s, got %d", http.StatusNotFound, path, response.Code)
+		}
+	}
+
+	if !router.Remove("api") {
+		test.Fatal("Expected api to be removed!")
+	} else if handler, _ := router.Resolve("/api/posts"); handler != nil {
+		test.Fatal("/api/posts still found!")
+	}
+}
+
+func TestRouterWalk(test *testing.T) {
+	var v1 = New()
+	v1.Add("/users/:id", &Handler{}, "user")
+	v1.Add("/users", &Handler{}, "users")
+
+	var api = New()
+	api.AddRouter("/v1", v1, "v1")
+
+	var router = New()
+	router.Add("/", &Handler{}, "index")
+	router.AddRouter("/api", api, "api")
+
+	var visited = make([]string, 0)
+	var err = router.Walk(func(namespace string, route *Route) error {
+		visited = append(visited, fmt.Sprintf("%s %s %s", namespace, route.Name(), route.Pattern()))
+		return nil
+	})
+
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if expected := []string{" index /", "api:v1 users /users", "api:v1 user /users/:id"}; !reflect.DeepEqual(visited, expected) {
+		test.Error("Incorrect routes!")
+		test.Errorf("Expected: %v", expected)
+		test.Fatalf("Got: %v", visited)
+	}
+
+	var stop = errors.New("stop")
+
+	if err = router.Walk(func(string, *Route) error { return stop }); err != stop {
+		test.Fatalf("Expected %v, got %v", stop, err)
+	}
+}
+
+func TestRouterRedirectTrailingSlash(test *testing.T) {
+	var router = New()
+	router.RedirectTrailingSlash = true
+	router.Add("/users", &Handler{}, "users")
+	router.Add("/posts/", &Handler{}, "posts")
+	router.Add("/static/*path", &Handler{}, "static")
+
+	var tests = []struct {
+		method, path string
+		status       int
+		location     string
+	}{
+		{http.MethodGet, "/users", http.StatusOK, ""},
+		{http.MethodGet, "/users/?page=2", http.StatusMovedPermanently, "/users?page=2"},
+		{http.MethodPost, "/users/", http.StatusPermanentRedirect, "/users"},
+		{http.MethodGet, "/posts/", http.StatusOK, ""},
+		{http.MethodHead, "/posts", http.StatusMovedPermanently, "/posts/"},
+		{http.MethodGet, "/static/css/", http.StatusOK, ""},
+	}
+
+	for _, t := range tests {
+		var response = httptest.NewRecorder()
+		router.ServeHTTP(response, httptest.NewRequest(t.method, t.path, nil))
+
+		if response.Code != t.status || response.Header().Get("Location") != t.location {
+			test.Errorf("Test '%s %s' failed!", t.method, t.path)
+			test.Fatalf("Got: %d %s", response.Code, response.Header().Get("Location"))
+		}
+	}
+}
+
+func TestRouterTrailingSlashPolicy(test *testing.T) {
+	var router = New()
+	router.RedirectTrailingSlash = true
+	router.Add("/", &Handler{}, "home")
+	router.Add("/users", &Handler{}, "users")
+	router.Add("/posts/", &Handler{}, "posts")
+	router.Add("/static/*path", &Handler{}, "static")
+
+	for _, policy := range []SlashPolicy{SlashAsRegistered, SlashAlways, SlashNever} {
+		router.TrailingSlash = policy
+
+		for _, name := range []string{"home", "users", "posts", "static"} {
+			var path, err = router.Reverse(name, "css/")
+
+			if err != nil {
+				test.Fatal(err)
+			} else if path == "" {
+				test.Fatalf("Expected non-empty path for %s", name)
+			}
+
+			var response = httptest.NewRecorder()
+			router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, path, nil))
+
+			if response.Code != http.StatusOK {
+				test.Fatalf("Expected %d for %s with policy %d, got %d", http.StatusOK, path, policy, response.Code)
+			}
+		}
+	}
+
+	router.TrailingSlash = SlashAlways
+
+	if path, _ := router.Reverse("users"); path != "/users/" {
+		test.Fatalf("Expected /users/, got %s", path)
+	}
+
+	router.TrailingSlash = SlashNever
+
+	if path, _ := router.Reverse("posts"); path != "/posts" {
+		test.Fatalf("Expected /posts, got %s", path)
+	} else if path, _ := router.Reverse("home"); path != "/" {
+		test.Fatalf("Expected /, got %s", path)
+	}
+}
+
+func TestRouterCleanPath(test *testing.T) {
+	var router = New()
+	router.CleanPath = true
+	router.RedirectTrailingSlash = true
+	router.Add("/a/c", &Handler{}, "c")
+	router.Add("/users/:id", &Handler{}, "user")
+
+	var tests = []struct {
+		method, path string
+		status       int
+		location     string
+	}{
+		{http.MethodGet, "/a/c", http.StatusOK, ""},
+		{http.MethodGet, "/a/b/../c", http.StatusMovedPermanently, "/a/c"},
+		{http.MethodHead, "/a/./c?x=1", http.StatusMovedPermanently, "/a/c?x=1"},
+		{http.MethodGet, "/a/b/../c/", http.StatusMovedPermanently, "/a/c"},
+		{http.MethodPost, "/a/b/../c", http.StatusOK, ""},
+		{http.MethodGet, "/users//5", http.StatusMovedPermanently, "/users/5"},
+		{http.MethodGet, "/users/../../a/c", http.StatusMovedPermanently, "/a/c"},
+	}
+
+	for _, t := range tests {
+		var response = httptest.NewRecorder()
+		router.ServeHTTP(response, httptest.NewRequest(t.method, t.path, nil))
+
+		if response.Code != t.status || response.Header().Get("Location") != t.location {
+			test.Errorf("Test '%s %s' failed!", t.method, t.path)
+			test.Fatalf("Got: %d %s", response.Code, response.Header().Get("Location"))
+		}
+	}
+}
+
+func TestRouterCaseInsensitive(test *testing.T) {
+	var router = New()
+	router.Add("/users/:id", &Handler{}, "user")
+
+	if handler, _ := router.Resolve("/USERS/abc"); handler != nil {
+		test.Fatal("Expected case-sensitive match by default!")
+	}
+
+	router.CaseInsensitive = true
+
+	if handler, parameters := router.Resolve("/USERS/AbC"); handler == nil {
+		test.Fatal("Expected handler!")
+	} else if !reflect.DeepEqual(parameters, []string{"AbC"}) {
+		test.Fatalf("Unexpected parameters: %v", parameters)
+	}
+}
+
+func TestRouterCaseInsensitiveAmbiguous(test *testing.T) {
+	var router = New(WithCaseInsensitive())
+	router.Add("/users", &Handler{name: "lower"}, "lower")
+	router.Add("/Users", &Handler{name: "upper"}, "upper")
+
+	for index := 0; index < 100; index++ {
+		if handler, _ := router.Resolve("/USERS"); handler != router.named("upper").handler {
+			test.Fatalf("Expected the first key in sort order to win, got %v", handler)
+		}
+	}
+
+	if handler, _ := router.Clone().Resolve("/USERS"); handler != router.named("upper").handler {
+		test.Fatal("Expected clones to fold the same way!")
+	}
+}
+
+func TestRouterCaseInsensitiveMounted(test *testing.T) {
+	var api = New(WithCaseInsensitive())
+	api.Add("/users/:id", &Handler{}, "user")
+
+	var strict = New()
+	strict.Add("/users/:id", &Handler{}, "user")
+
+	var router = New()
+	router.AddRouter("/api", api, "api")
+	router.AddRouter("/strict", strict, "strict")
+	router.Add("/home", &Handler{}, "home")
+
+	var tests = map[string]bool{
+		"/api/USERS/1":    true,
+		"/API/users/1":    false,
+		"/strict/USERS/1": false,
+		"/HOME":           false,
+	}
+
+	for path, expected := range tests {
+		if handler, _ := router.Resolve(path); (handler != nil) != expected {
+			test.Fatalf("Expected match %v for %s", expected, path)
+		}
+	}
+}
+
+func TestRouterCaseInsensitiveParameters(test *testing.T) {
+	var parameters map[string]string
+
+	var api = New()
+	api.Add("/users/:id/files/*path", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parameters = Params(r.Context())
+	}), "file")
+
+	var router = New(WithCaseInsensitive())
+	router.AddRouter("/API/:Tenant", api, "api")
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/AcMe/USERS/AbC/Files/Docs/Read.MD", nil))
+
+	if expected := map[string]string{"Tenant": "AcMe", "id": "AbC", "path": "Docs/Read.MD"}; !reflect.DeepEqual(parameters, expected) {
+		test.Fatalf("Expected %v, got %v", expected, parameters)
+	} else if path, err := router.Reverse("api:file", "AcMe", "AbC", "Docs/Read.MD"); err != nil || path != "/API/AcMe/users/AbC/files/Docs/Read.MD" {
+		test.Fatalf("Unexpected path: %s (%v)", path, err)
+	}
+}
+
+func TestRouterHandleOPTIONS(test *testing.T) {
+	var router = New()
+	router.Add("/users", &Handler{}, "users.list", http.MethodGet)
+	router.Add("/users", &Handler{}, "users.create", http.MethodPost)
+	router.Add("/posts", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), "posts.options", http.MethodOptions)
+
+	var response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodOptions, "/users", nil))
+
+	if response.Code != http.StatusMethodNotAllowed {
+		test.Fatalf("Expected %d, got %d", http.StatusMethodNotAllowed, response.Code)
+	}
+
+	router.HandleOPTIONS = true
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodOptions, "/users", nil))
+
+	if response.Code != http.StatusNoContent {
+		test.Fatalf("Expected %d, got %d", http.StatusNoContent, response.Code)
+	} else if allow := response.Header().Get("Allow"); allow != "GET, OPTIONS, POST" {
+		test.Fatalf("Unexpected Allow header: %s", allow)
+	}
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodOptions, "/posts", nil))
+
+	if response.Code != http.StatusOK {
+		test.Fatalf("Expected %d, got %d", http.StatusOK, response.Code)
+	}
+}
+
+func TestRouterStreaming(test *testing.T) {
+	var response *httptest.ResponseRecorder
+	var flushed []bool
+
+	var router = New(WithHandleHEAD(), WithOnRequest(func(RequestStats) {}))
+	router.Use(Logger(func(LogEntry) {}), Compress(5), Recover(nil))
+	router.GET("/events", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		for index := 0; index < 2; index++ {
+			fmt.Fprintf(w, "data: %d\n\n", index)
+			w.(http.Flusher).Flush()
+			flushed = append(flushed, response.Flushed)
+		}
+	}))
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		response, flushed = httptest.NewRecorder(), nil
+
+		var request = httptest.NewRequest(method, "/events", nil)
+		request.Header.Set("Accept-Encoding", "gzip")
+		router.ServeHTTP(response, request)
+
+		if !reflect.DeepEqual(flushed, []bool{true, true}) {
+			test.Fatalf("Expected every %s event to be flushed, got %v", method, flushed)
+		}
+	}
+}
+
+func TestRouterHandleHEAD(test *testing.T) {
+	var router = New()
+	router.Add("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	}), "users.list", http.MethodGet)
+	router.Add("/posts", &Handler{}, "posts.list", http.MethodGet)
+	router.Add("/posts", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}), "posts.head", http.MethodHead)
+
+	var response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodHead, "/users", nil))
+
+	if response.Code != http.StatusMethodNotAllowed {
+		test.Fatalf("Expected %d, got %d", http.StatusMethodNotAllowed, response.Code)
+	}
+
+	router.HandleHEAD = true
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodHead, "/users", nil))
+
+	if response.Code != http.StatusOK {
+		test.Fatalf("Expected %d, got %d", http.StatusOK, response.Code)
+	} else if contentType := response.Header().Get("Content-Type"); contentType != "application/json" {
+		test.Fatalf("Unexpected Content-Type header: %s", contentType)
+	} else if response.Body.Len() != 0 {
+		test.Fatalf("Expected empty body, got %q", response.Body.String())
+	}
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodHead, "/posts", nil))
+
+	if response.Code != http.StatusAccepted {
+		test.Fatalf("Expected %d, got %d", http.StatusAccepted, response.Code)
+	}
+
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodPost, "/users", nil))
+
+	if allow := response.Header().Get("Allow"); allow != "GET, HEAD" {
+		test.Fatalf("Unexpected Allow header: %s", allow)
+	}
+}
+
+func TestRouterGreedy(test *testing.T) {
+	var filepath string
+	var router = New()
+
+	router.Add("/static/*filepath", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filepath = Param(r.Context(), "filepath")
+	}), "static")
+
+	for path, expected := range map[string]string{
+		"/static/css/app.css":        "css/app.css",
+		"/static/app.js":             "app.js",
+		"/static/img/icons/logo.svg": "img/icons/logo.svg",
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+
+		if filepath != expected {
+			test.Fatalf("Expected %s, got %s", expected, filepath)
+		}
+	}
+
+	if handler, _ := router.Resolve("/static"); handler != nil {
+		test.Fatal("Expected no handler for an empty remainder!")
+	}
+
+	if path, err := router.Reverse("static", "css/app.css"); err != nil {
+		test.Fatal(err)
+	} else if path != "/static/css/app.css" {
+		test.Fatal(path)
+	}
+}
+
+func TestRouterTypedParameters(test *testing.T) {
+	var number, uuid, slug, any = &Handler{}, &Handler{}, &Handler{}, &Handler{}
+	var router = New()
+
+	router.Add("/posts/:id:int", number, "post.id")
+	router.Add("/posts/:id:uuid", uuid, "post.uuid")
+	router.Add("/posts/:slug:slug", slug, "post.slug")
+	router.Add("/posts/:other", any, "post.other")
+
+	var tests = map[string]http.Handler{
+		"/posts/42": number,
+		"/posts/0b5ad2f4-1a74-4cf4-9f27-0de3c8b1d6a1": uuid,
+		"/posts/hello-world":                          slug,
+		"/posts/Hello_World":                          any,
+	}
+
+	for path, expected := range tests {
+		if handler, _ := router.Resolve(path); handler != expected {
+			test.Fatalf("Test '%s' failed!", path)
+		}
+	}
+
+	if path, err := router.Reverse("post.id", "42"); err != nil {
+		test.Fatal(err)
+	} else if path != "/posts/42" {
+		test.Fatal(path)
+	}
+
+	defer func() {
+		if recover() == nil {
+			test.Fatal("Expected panic for unknown parameter type!")
+		}
+	}()
+
+	router.Add("/posts/:id:float", &Handler{}, "post.float")
+}
+
+func TestRouterParameterKeys(test *testing.T) {
+	var router = New()
+	router.Add("/users/:id:int", &Handler{}, "user")
+	router.Add("/files/*path", &Handler{}, "files")
+
+	if handler, parameters := router.Resolve("/users/:int"); handler != nil {
+		test.Fatalf("Expected /users/:int not to match, got %v", parameters)
+	} else if _, parameters := router.Resolve("/files/*"); !reflect.DeepEqual(parameters, []string{"*"}) {
+		test.Fatalf("Expected [*], got %v", parameters)
+	} else if _, parameters := router.Resolve("/users/42"); !reflect.DeepEqual(parameters, []string{"42"}) {
+		test.Fatalf("Expected [42], got %v", parameters)
+	}
+}
+
+func TestRouterSpecificity(test *testing.T) {
+	var static, typed, parameter, greedy = &Handler{}, &Handler{}, &Handler{}, &Handler{}
+	var routes = []func(router *Router){
+		func(router *Router) { router.Add("/users/me", static, "me") },
+		func(router *Router) { router.Add("/users/:id:int", typed, "id") },
+		func(router *Router) { router.Add("/users/:name", parameter, "name") },
+		func(router *Router) { router.Add("/users/*path", greedy, "path") },
+	}
+	var tests = map[string]http.Handler{
+		"/users/me":   static,
+		"/users/42":   typed,
+		"/users/john": parameter,
+		"/users/a/b":  greedy,
+	}
+
+	for _, order := range [][]int{{0, 1, 2, 3}, {3, 2, 1, 0}, {2, 0, 3, 1}} {
+		var router = New()
+
+		for _, index := range order {
+			routes[index](router)
+		}
+
+		for path, expected := range tests {
+			if handler, _ := router.Resolve(path); handler != expected {
+				test.Fatalf("Test '%s' failed for order %v!", path, order)
+			}
+		}
+	}
+}
+
+func TestRouterGroup(test *testing.T) {
+	var calls = make([]string, 0)
+	var middleware = func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	var router = New()
+	var api = router.Group("/api", "api", middleware("api"))
+	var v1 = api.Group("/v1", "v1", middleware("v1"))
+	v1.Add("/users/:id", &Handler{}, "user")
+	router.Add("/health", &Handler{}, "health")
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	if expected := []string{"api", "v1"}; !reflect.DeepEqual(calls, expected) {
+		test.Error("Incorrect middleware!")
+		test.Errorf("Expected: %v", expected)
+		test.Fatalf("Got: %v", calls)
+	}
+
+	if path, err := router.Reverse("api:v1:user", "1"); err != nil {
+		test.Fatal(err)
+	} else if path != "/api/v1/users/1" {
+		test.Fatal(path)
+	}
+}
+
+func TestRouterRequireQuery(test *testing.T) {
+	var served string
+	var handler = func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served = name
+		})
+	}
+
+	var router = New()
+	router.Add("/search", handler("image"), "search.image", http.MethodGet).RequireQuery(map[string]string{"type": "image"})
+	router.Add("/search", handler("video"), "search.video", http.MethodGet).RequireQuery(map[string]string{"type": "video"})
+	router.Add("/search", handler("paged"), "search.paged", http.MethodPost).RequireQuery(map[string]string{"page": ""})
+
+	var tests = map[string]struct {
+		method string
+		served string
+		status int
+	}{
+		"/search?type=image":       {http.MethodGet, "image", http.StatusOK},
+		"/search?type=video&q=cat": {http.MethodGet, "video", http.StatusOK},
+		"/search?type=audio":       {http.MethodGet, "", http.StatusNotFound},
+		"/search?page=2":           {http.MethodPost, "paged", http.StatusOK},
+		"/search?page=3":           {http.MethodGet, "", http.StatusMethodNotAllowed},
+		"/search":                  {http.MethodGet, "", http.StatusNotFound},
+	}
+
+	for path, expected := range tests {
+		var response = httptest.NewRecorder()
+		served = ""
+
+		router.ServeHTTP(response, httptest.NewRequest(expected.method, path, nil))
+
+		if served != expected.served || response.Code != expected.status {
+			test.Errorf("Test '%s %s' failed!", expected.method, path)
+			test.Fatalf("Got: %s %d", served, response.Code)
+		}
+	}
+}
+
+func TestRouterConstraintBacktracking(test *testing.T) {
+	var served string
+	var handler = func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served = name + ":" + Param(r.Context(), "id")
+		})
+	}
+
+	for _, router := range []*Router{New(), New(WithCache(16))} {
+		router.Add("/users/me", handler("me"), "me").RequireQuery(map[string]string{"x": "1"})
+		router.Add("/users/beta", handler("beta"), "beta").Require(MatchHeader("X-Beta", ""))
+		router.Add("/users/:id", handler("user"), "user")
+
+		var tests = []struct {
+			path   string
+			header string
+			served string
+		}{
+			{"/users/me?x=1", "", "me:"},
+			{"/users/me", "", "user:me"},
+			{"/users/me?x=1", "", "me:"},
+			{"/users/beta", "", "user:beta"},
+			{"/users/beta", "on", "beta:"},
+			{"/users/beta", "", "user:beta"},
+		}
+
+		for _, t := range tests {
+			var request = httptest.NewRequest(http.MethodGet, t.path, nil)
+			served = ""
+
+			if t.header != "" {
+				request.Header.Set("X-Beta", t.header)
+			}
+
+			router.ServeHTTP(httptest.NewRecorder(), request)
+
+			if served != t.served {
+				test.Fatalf("Expected %s for %s, got %s", t.served, t.path, served)
+			}
+		}
+	}
+}
+
+func TestRouterClone(test *testing.T) {
+	var api = New()
+	api.Add("/users/:id", &Handler{}, "user").SetMeta("scope", "read")
+
+	var router = New()
+	router.Add("/", &Handler{}, "home")
+	router.AddRouter("/api", api, "api")
+
+	var clone = router.Clone()
+	clone.Add("/about", &Handler{}, "about")
+	clone.Remove("home")
+	clone.Group("/admin", "admin").Add("/", &Handler{}, "index")
+
+	var route, _, _ = clone.ResolveRemaining("/api/users/1")
+	route.SetMeta("scope", "write").Named("member")
+
+	if _, err := router.Reverse("about"); err != ErrNameNotFound {
+		test.Fatal("Expected original to be unaffected by Add!")
+	} else if _, err := router.Reverse("home"); err != nil {
+		test.Fatal("Expected original to be unaffected by Remove!")
+	} else if _, err := router.Reverse("admin:index"); err != ErrNamespaceNotFound {
+		test.Fatal("Expected original to be unaffected by Group!")
+	} else if _, err := router.Reverse("api:user", "1"); err != nil {
+		test.Fatal("Expected original to be unaffected by Named!")
+	}
+
+	if original, _, _ := router.ResolveRemaining("/api/users/1"); original == route {
+		test.Fatal("Expected routes to be copied!")
+	} else if scope, _ := original.Meta("scope"); scope != "read" {
+		test.Fatalf("Expected read, got %v", scope)
+	}
+
+	for name, expected := range map[string]string{"about": "/about", "admin:index": "/admin", "api:member": "/api/users/1"} {
+		if path, err := clone.Reverse(name, "1"); err != nil || path != expected {
+			test.Fatalf("Expected %s, got %s (%v)", expected, path, err)
+		}
+	}
+
+	if handler, _ := clone.Resolve("/"); handler != nil {
+		test.Fatal("Expected removed route to stay removed in the clone!")
+	}
+}
+
+func TestRouterAddUnique(test *testing.T) {
+	var first, second = &Handler{}, &Handler{}
+	var router = New()
+
+	if _, err := router.AddUnique("/users", first, "users"); err != nil {
+		test.Fatal(err)
+	} else if _, err := router.AddUnique("/people", second, "users"); err == nil {
+		test.Fatal("Expected duplicate error!")
+	} else if duplicate, ok := err.(*DuplicateError); !ok || duplicate.Name != "users" {
+		test.Fatalf("Unexpected error: %v", err)
+	} else if handler, _ := router.Resolve("/people"); handler != nil {
+		test.Fatal("Expected duplicate route not to be added!")
+	}
+
+	if _, err := router.AddUnique("/posts", first, ""); err != nil {
+		test.Fatal(err)
+	} else if _, err := router.AddUnique("/comments", second, ""); err != nil {
+		test.Fatal("Expected unnamed routes to be allowed!")
+	} else if _, err := router.AddUnique("/users/:id:number", first, "user"); err == nil {
+		test.Fatal("Expected pattern error!")
+	}
+
+	router.Add("/people", second, "users")
+
+	if handler, _ := router.Resolve("/people"); handler != second {
+		test.Fatal("Expected Add to replace the route!")
+	}
+}
+
+func TestRouterMerge(test *testing.T) {
+	var called []string
+	var track = func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = append(called, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	var v1 = New()
+	v1.Add("/status", &Handler{}, "status")
+
+	var users = New()
+	users.Use(track("users"))
+	users.Add("/users/:id", &Handler{}, "user").Use(track("user"))
+	users.AddRouter("/v1", v1, "v1")
+
+	var router = New()
+	router.Add("/", &Handler{}, "home")
+
+	if err := router.Merge(users); err != nil {
+		test.Fatal(err)
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/status", nil))
+
+	if !reflect.DeepEqual(called, []string{"users", "user", "users"}) {
+		test.Fatalf("Unexpected middleware calls: %v", called)
+	}
+
+	for name, expected := range map[string]string{"home": "/", "user": "/users/1", "v1:status": "/v1/status"} {
+		if path, err := router.Reverse(name, "1"); err != nil || path != expected {
+			test.Fatalf("Expected %s, got %s (%v)", expected, path, err)
+		}
+	}
+
+	users.Add("/posts", &Handler{}, "posts")
+
+	if handler, _ := router.Resolve("/posts"); handler != nil {
+		test.Fatal("Expected merged routes to be copied!")
+	}
+
+	var duplicate = New()
+	duplicate.Add("/other", &Handler{}, "other")
+	duplicate.Add("/home", &Handler{}, "home")
+
+	if err, ok := router.Merge(duplicate).(*DuplicateError); !ok || err.Name != "home" {
+		test.Fatalf("Expected duplicate error, got %v", err)
+	} else if handler, _ := router.Resolve("/other"); handler != nil {
+		test.Fatal("Expected nothing to be merged on conflict!")
+	}
+
+	duplicate = New()
+	duplicate.AddRouter("/v2", New(), "v1")
+
+	if err, ok := router.Merge(duplicate).(*DuplicateError); !ok || err.Name != "v1" {
+		test.Fatalf("Expected duplicate error, got %v", err)
+	}
+}
+
+func TestRouterDescribe(test *testing.T) {
+	var api = New()
+	api.Add("/users/:id", &Handler{}, "user", http.MethodGet).SetMeta("scope", "read")
+	api.Add("/users", &Handler{}, "users", http.MethodPost)
+
+	var router = New()
+	router.Add("/", &Handler{}, "home")
+	router.AddRouter("/api", api, "api")
+
+	var data, err = json.Marshal(router)
+
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	var expected = `[` +
+		`{"name":"home","prefix":"/","pattern":"/"},` +
+		`{"name":"api:users","namespace":"api","prefix":"/api","pattern":"/api/users","methods":["POST"]},` +
+		`{"name":"api:user","namespace":"api","prefix":"/api","pattern":"/api/users/:id","methods":["GET"],"meta":{"scope":"read"}}` +
+		`]`
+
+	if string(data) != expected {
+		test.Fatalf("Expected %s, got %s", expected, data)
+	}
+}
+
+type PriorityHandler struct {
+	Handler
+
+	priority int
+}
+
+func (handler *PriorityHandler) Priority() int {
+	return handler.priority
+}
+
+func TestRouterPriority(test *testing.T) {
+	var fallback = &PriorityHandler{priority: -10}
+	var preferred = &PriorityHandler{priority: 10}
+	var plain, other = &Handler{}, &Handler{}
+
+	var router = New()
+	router.Add("/files/*path", fallback, "fallback")
+	router.Add("/files/*path", plain, "plain", http.MethodGet)
+	router.Add("/files/*path", other, "other")
+	router.Add("/files/*path", preferred, "preferred", http.MethodPost)
+
+	var expected = []*Route{}
+
+	for _, name := range []string{"preferred", "plain", "other", "fallback"} {
+		expected = append(expected, router.names[name])
+	}
+
+	if leaf := router.node(segments("/files/*path")); !reflect.DeepEqual(leaf.routes, expected) {
+		test.Fatal("Expected routes to be ordered by descending priority!")
+	}
+
+	if handler, _, _ := router.ResolveMethod(http.MethodGet, "/files/a"); handler != plain {
+		test.Fatal("Expected GET to reach the zero-priority route!")
+	} else if handler, _, _ := router.ResolveMethod(http.MethodDelete, "/files/a"); handler != other {
+		test.Fatal("Expected equal priorities to keep insertion order!")
+	} else if handler, _, _ := router.ResolveMethod(http.MethodPost, "/files/a"); handler != preferred {
+		test.Fatal("Expected high priority route to be tried first!")
+	}
+}
+
+func TestRouterValidate(test *testing.T) {
+	var api = New()
+	api.Add("/users/:id", &Handler{}, "user", http.MethodGet)
+	api.Add("/users/:name", &Handler{}, "user.name", http.MethodGet, http.MethodPost)
+	api.Add("/users/:id", &Handler{}, "user.delete", http.MethodDelete)
+
+	var router = New()
+	router.Add("/search", &Handler{}, "search.image").RequireQuery(map[string]string{"type": "image"})
+	router.Add("/search", &Handler{}, "search")
+	router.Add("/posts", &Handler{}, "posts")
+	router.Add("/posts", &Handler{}, "posts.recent").RequireQuery(map[string]string{"sort": "recent"})
+	router.Add("/proxy", &Handler{}, "proxy.get", http.MethodGet)
+	router.Add("/proxy", &Handler{}, "proxy")
+	router.Add("/proxy", &Handler{}, "proxy.post", http.MethodPost)
+	router.AddRouter("/api", api, "api")
+
+	var conflicts = router.Validate()
+	var expected = []Conflict{
+		{"/api/users/:id", "api:user", "api:user.name"},
+		{"/posts", "posts", "posts.recent"},
+	}
+
+	if !reflect.DeepEqual(conflicts, expected) {
+		test.Errorf("Expected: %v", expected)
+		test.Fatalf("Got: %v", conflicts)
+	} else if message := conflicts[0].Error(); message != "Route 'api:user' shadows 'api:user.name' at /api/users/:id!" {
+		test.Fatal(message)
+	}
+}
+
+func TestRouterResolveAllocs(test *testing.T) {
+	var router = New()
+	router.Add("/some/static/path", &Handler{}, "")
+	router.Add("/static/*path", &Handler{}, "")
+
+	if allocs := testing.AllocsPerRun(100, func() { router.Resolve("/some/static/path") }); allocs != 0 {
+		test.Fatalf("Expected 0 allocations, got %v", allocs)
+	} else if allocs := testing.AllocsPerRun(100, func() { router.Resolve("/static/css/app.css") }); allocs != 1 {
+		test.Fatalf("Expected 1 allocation, got %v", allocs)
+	}
+}
+
+func TestRouterServeHTTPAllocs(test *testing.T) {
+	var router = New()
+	router.Add("/some/static/path", &Handler{}, "")
+	router.Add(":user/activity/:activity", &Handler{}, "")
+
+	// The match, which is also the request context, and the request copy;
+	// static routes and short parameter lists allocate nothing on top.
+	for _, path := range []string{"/some/static/path", "/1/activity/2"} {
+		var request = httptest.NewRequest(http.MethodGet, path, nil)
+
+		if allocs := testing.AllocsPerRun(100, func() { router.ServeHTTP(nil, request) }); allocs != 2 {
+			test.Fatalf("Expected 2 allocations for %s, got %v", path, allocs)
+		}
+	}
+}
+
+func BenchmarkRouterTwoParameters(benchmark *testing.B) {
+	var router = New()
+
+	router.Add(":user/activity/:activity", &Handler{}, "")
+
+	for i := 0; i < benchmark.N; i++ {
+		router.Resolve("/1/activity/2")
+	}
+}
+
+func BenchmarkRouterTypedParameters(benchmark *testing.B) {
+	var router = New()
+
+	router.Add("/users/:id:int", &Handler{}, "")
+	router.Add("/users/:id:uuid", &Handler{}, "")
+
+	for i := 0; i < benchmark.N; i++ {
+		router.Resolve("/users/0b5ad2f4-1a74-4cf4-9f27-0de3c8b1d6a1")
+	}
+}
+
+func BenchmarkRouterGreedy(benchmark *testing.B) {
+	var router = New()
+
+	router.Add("static/*path", &Handler{}, "")
+
+	for i := 0; i < benchmark.N; i++ {
+		router.Resolve("/static/path/to/static")
+	}
+}
+
+func BenchmarkRouterStatic(benchmark *testing.B) {
+	var router = New()
+
+	router.Add("/some/static/path", &Handler{}, "")
+	router.Add("/some/static2/path", &Handler{}, "")
+	router.Add("/some/static/path2", &Handler{}, "")
+
+	for i := 0; i < benchmark.N; i++ {
+		router.Resolve("/some/static/path")
+	}
+}
+
+func BenchmarkRouterLarge(benchmark *testing.B) {
+	var router = New()
+
+	for i := 0; i < 2000; i++ {
+		router.Add(fmt.Sprintf("/resource%d/:id/items/%d", i%200, i), &Handler{}, "")
+	}
+
+	for i := 0; i < benchmark.N; i++ {
+		router.Resolve("/resource199/42/items/1999")
+	}
+}
+
+func BenchmarkRouterNested(benchmark *testing.B) {
+	var v1 = New()
+	v1.Add("/users/:id", &Handler{}, "user")
+
+	var api = New()
+	api.AddRouter("/v1", v1, "v1")
+
+	var router = New()
+	router.AddRouter("/:tenant/api", api, "api")
+
+	for i := 0; i < benchmark.N; i++ {
+		router.Resolve("/acme/api/v1/users/1")
+	}
+}
+
+func BenchmarkRouterReverseGreedy(benchmark *testing.B) {
+	var router = New()
+	router.Add("/static/*path", &Handler{}, "static")
+
+	for i := 0; i < benchmark.N; i++ {
+		router.Reverse("static", "css/app.css")
+	}
+}
+
+func BenchmarkRouterReverse(benchmark *testing.B) {
+	var api = New()
+	api.Add("/:name/endpoint/:id", &Handler{}, "endpoint")
+
+	var router = New()
+	router.AddRouter("api", api, "api")
+
+	var parameters = []string{"n", "1"}
+
+	for i := 0; i < benchmark.N; i++ {
+		router.Reverse("api:endpoint", parameters...)
+	}
+}
+
+func BenchmarkRouterServeHTTP(benchmark *testing.B) {
+	var request, _ = http.NewRequest("GET", "/1/activity/2", nil)
+	var router = New()
+
+	router.Add(":user/activity/:activity", &Handler{}, "")
+
+	for i := 0; i < benchmark.N; i++ {
+		router.ServeHTTP(nil, request)
+	}
+}
+
+func BenchmarkRouterServeHTTPStatic(benchmark *testing.B) {
+	var request, _ = http.NewRequest("GET", "/some/static/path", nil)
+	var router = New()
+
+	router.Add("/some/static/path", &Handler{}, "")
+	benchmark.ReportAllocs()
 
 	for i := 0; i < benchmark.N; i++ {
 		router.ServeHTTP(nil, request)
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+type FileServer struct {
+	FS      fs.FS
+	Listing bool
+}
+
+func (server *FileServer) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	var name = "."
+
+	if m, ok := request.Context().Value(matchKey).(*match); ok && m.route != nil && m.route.greedy && len(m.parameters) > 0 {
+		name = strings.Trim(path.Clean("/"+m.parameters[len(m.parameters)-1]), "/")
+	}
+
+	if name == "" {
+		name = "."
+	}
+
+	if info, err := fs.Stat(server.FS, name); err != nil {
+		notFound(response, request)
+		return
+	} else if info.IsDir() {
+		if _, err := fs.Stat(server.FS, path.Join(name, "index.html")); err == nil && strings.HasSuffix(request.URL.Path, "/") {
+			name = path.Join(name, "index.html")
+		} else if err != nil && !server.Listing {
+			notFound(response, request)
+			return
+		}
+	}
+
+	http.ServeFileFS(response, request, server.FS, name)
+}
@@ -0,0 +1,123 @@
+package routes
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFileServer(test *testing.T) {
+	var files = fstest.MapFS{
+		"css/app.css":      &fstest.MapFile{Data: []byte("body {}")},
+		"docs/index.html":  &fstest.MapFile{Data: []byte("<h1>Docs</h1>")},
+		"images/logo.txt":  &fstest.MapFile{Data: []byte("logo")},
+		"images/other.txt": &fstest.MapFile{Data: []byte("other")},
+	}
+
+	var router = New()
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	router.Add("/static/*path", &FileServer{FS: files}, "static")
+	router.Add("/browse/*path", &FileServer{FS: files, Listing: true}, "browse")
+
+	var tests = map[string]int{
+		"/static/css/app.css":     http.StatusOK,
+		"/static/docs/":           http.StatusOK,
+		"/static/images/":         http.StatusTeapot,
+		"/static/missing.css":     http.StatusTeapot,
+		"/browse/images/":         http.StatusOK,
+		"/browse/images/logo.txt": http.StatusOK,
+	}
+
+	for path, status := range tests {
+		var response = httptest.NewRecorder()
+		router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if response.Code != status {
+			test.Fatalf("Expected %d for %s, got %d", status, path, response.Code)
+		}
+	}
+
+	var response = httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/static/css/app.css", nil))
+
+	if body := response.Body.String(); body != "body {}" {
+		test.Fatalf("Unexpected body: %s", body)
+	} else if contentType := response.Header().Get("Content-Type"); contentType != "text/css; charset=utf-8" {
+		test.Fatalf("Unexpected Content-Type: %s", contentType)
+	}
+
+	if path, err := router.Reverse("static", "css/app.css"); err != nil {
+		test.Fatal(err)
+	} else if path != "/static/css/app.css" {
+		test.Fatal(path)
+	}
+}
+
+//go:embed testdata/static
+var embedded embed.FS
+
+func TestFileServerEmbed(test *testing.T) {
+	var files, err = fs.Sub(embedded, "testdata/static")
+
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	var router = New()
+	router.Add("/assets/*path?", &FileServer{FS: files}, "assets")
+
+	var tests = []struct {
+		path, contentType string
+		status            int
+	}{
+		{"/assets/", "text/html; charset=utf-8", http.StatusOK},
+		{"/assets/js/app.js", "text/javascript; charset=utf-8", http.StatusOK},
+		{"/assets/js/", "", http.StatusNotFound},
+		{"/assets/missing.js", "", http.StatusNotFound},
+	}
+
+	for _, t := range tests {
+		var response = httptest.NewRecorder()
+		router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, t.path, nil))
+
+		if response.Code != t.status {
+			test.Fatalf("Expected %d for %s, got %d", t.status, t.path, response.Code)
+		} else if contentType := response.Header().Get("Content-Type"); t.contentType != "" && contentType != t.contentType {
+			test.Fatalf("Unexpected Content-Type for %s: %s", t.path, contentType)
+		}
+	}
+}
+
+func TestFileServerPrefixParameters(test *testing.T) {
+	var files = fstest.MapFS{
+		"acme":       &fstest.MapFile{Data: []byte("secret")},
+		"index.html": &fstest.MapFile{Data: []byte("<h1>Assets</h1>")},
+		"app.css":    &fstest.MapFile{Data: []byte("body {}")},
+	}
+
+	var assets = New()
+	assets.Add("/assets", &FileServer{FS: files}, "assets")
+	assets.Add("/files/*path", &FileServer{FS: files}, "files")
+
+	var router = New()
+	router.AddRouter("/:tenant", assets, "tenant")
+
+	var tests = map[string]string{
+		"/acme/assets/":       "<h1>Assets</h1>",
+		"/acme/files/app.css": "body {}",
+	}
+
+	for path, expected := range tests {
+		var response = httptest.NewRecorder()
+		router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if response.Code != http.StatusOK || response.Body.String() != expected {
+			test.Fatalf("Expected %q for %s, got %d %q", expected, path, response.Code, response.Body.String())
+		}
+	}
+}
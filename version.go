@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const versionKey = key("version")
+
+var VersionHeaders = []string{"Accept-Version", "X-API-Version"}
+
+type version struct {
+	name   string
+	router *Router
+}
+
+type VersionRouter struct {
+	NotFoundHandler http.Handler
+
+	versions []*version
+}
+
+func Version(ctx context.Context) string {
+	if version, ok := ctx.Value(versionKey).(string); ok {
+		return version
+	}
+
+	return ""
+}
+
+func normalize(name string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), "v")
+}
+
+func newer(left, right string) bool {
+	var lefts, rights = strings.Split(left, "."), strings.Split(right, ".")
+
+	for index := 0; index < len(lefts) && index < len(rights); index++ {
+		var l, lerr = strconv.Atoi(lefts[index])
+		var r, rerr = strconv.Atoi(rights[index])
+
+		if lerr != nil || rerr != nil {
+			if lefts[index] != rights[index] {
+				return lefts[index] > rights[index]
+			}
+		} else if l != r {
+			return l > r
+		}
+	}
+
+	return len(lefts) > len(rights)
+}
+
+func (router *VersionRouter) Add(name string, node *Router) {
+	router.versions = append(router.versions, &version{name: normalize(name), router: node})
+}
+
+func (router *VersionRouter) latest() *version {
+	var latest *version
+
+	for _, version := range router.versions {
+		if latest == nil || newer(version.name, latest.name) {
+			latest = version
+		}
+	}
+
+	return latest
+}
+
+func (router *VersionRouter) Resolve(request *http.Request) (*Router, string) {
+	for _, header := range VersionHeaders {
+		if value := request.Header.Get(header); value != "" {
+			for _, version := range router.versions {
+				if version.name == normalize(value) {
+					return version.router, version.name
+				}
+			}
+
+			return nil, ""
+		}
+	}
+
+	if latest := router.latest(); latest != nil {
+		return latest.router, latest.name
+	}
+
+	return nil, ""
+}
+
+func (router *VersionRouter) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	if node, name := router.Resolve(request); node != nil {
+		node.ServeHTTP(response, request.WithContext(context.WithValue(request.Context(), versionKey, name)))
+	} else if router.NotFoundHandler != nil {
+		router.NotFoundHandler.ServeHTTP(response, request)
+	} else {
+		http.NotFound(response, request)
+	}
+}
@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVersionRouter(test *testing.T) {
+	var served string
+	var router = &VersionRouter{}
+
+	for _, name := range []string{"1", "2", "1.5"} {
+		var node = New()
+		node.GET("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served = Version(r.Context())
+		}))
+		router.Add(name, node)
+	}
+
+	var tests = map[[2]string]string{
+		{"Accept-Version", "1"}:  "1",
+		{"Accept-Version", "v2"}: "2",
+		{"X-API-Version", "1.5"}: "1.5",
+		{"", ""}:                 "2",
+	}
+
+	for header, expected := range tests {
+		served = ""
+
+		var request = httptest.NewRequest(http.MethodGet, "/users", nil)
+
+		if header[0] != "" {
+			request.Header.Set(header[0], header[1])
+		}
+
+		router.ServeHTTP(httptest.NewRecorder(), request)
+
+		if served != expected {
+			test.Fatalf("Expected version %s for %v, got %s", expected, header, served)
+		}
+	}
+
+	var response = httptest.NewRecorder()
+	var request = httptest.NewRequest(http.MethodGet, "/users", nil)
+	request.Header.Set("Accept-Version", "3")
+	router.ServeHTTP(response, request)
+
+	if response.Code != http.StatusNotFound {
+		test.Fatalf("Expected %d, got %d", http.StatusNotFound, response.Code)
+	}
+}